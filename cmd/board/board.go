@@ -0,0 +1,302 @@
+package board
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/config"
+)
+
+var (
+	store       cmdutil.Store
+	columnsVar  string
+	forceVar    bool
+	createFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "columns",
+			Usage:       "comma separated list names, the default lists if empty",
+			Destination: &columnsVar,
+		},
+	}, store.Flags()...)
+	rmFlags = append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "delete the board even if it has cards",
+			Destination: &forceVar,
+		},
+	}, store.Flags()...)
+)
+
+// board is a board as it is printed.
+type board struct {
+	Id, Name string
+	Lists    []string
+	Cards    int
+}
+
+func newBoard(ctx context.Context, b *backend.Board) (board, error) {
+	out := board{Id: b.Id, Name: b.Name, Lists: []string{}}
+	lists, err := b.Lists(ctx)
+	if err != nil {
+		return out, err
+	}
+	for _, l := range lists {
+		out.Lists = append(out.Lists, l.Name)
+		cards, err := b.GetBackend().ListCards(ctx, l.Id)
+		if err != nil {
+			return out, err
+		}
+		out.Cards += len(cards)
+	}
+	return out, nil
+}
+
+var header = []string{"ID", "NAME", "LISTS", "CARDS"}
+
+func (b board) row() []string {
+	return []string{b.Id, b.Name, strings.Join(b.Lists, ","), strconv.Itoa(b.Cards)}
+}
+
+// printBoard prints a single board, as a JSON object rather than an array.
+func printBoard(c *cli.Context, ctx context.Context, b *backend.Board) error {
+	out, err := newBoard(ctx, b)
+	if err != nil {
+		return err
+	}
+	return store.Print(c, out, header, [][]string{out.row()})
+}
+
+// columns returns the list names given with --columns.
+func columns() ([]string, error) {
+	if columnsVar == "" {
+		return config.DefaultList, nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(columnsVar, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("empty list name in --columns %q: %w", columnsVar, cmdutil.ErrUsage)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("list %q given twice in --columns: %w", name, cmdutil.ErrUsage)
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// unused fails with backend.ErrConflict if a board is called name, as the
+// commands open boards by name.
+func unused(ctx context.Context, be backend.Backend, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("board name is empty: %w", cmdutil.ErrUsage)
+	}
+	_, err := cmdutil.Board(ctx, be, name)
+	if err == nil {
+		return fmt.Errorf("board %q already exists: %w", name, backend.ErrConflict)
+	}
+	if errors.Is(err, backend.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func Create(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME")
+	if err != nil {
+		return err
+	}
+	names, err := columns()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[0])
+	b := &backend.Board{Id: uuid.New().String(), Name: name}
+	// The board and its lists are added together so a failure leaves no
+	// empty board behind to block the name.
+	err = backend.RunTx(ctx, be, func(be backend.Backend) error {
+		if err := unused(ctx, be, name); err != nil {
+			return err
+		}
+		if err := be.AddBoard(ctx, b); err != nil {
+			return err
+		}
+		for i, list := range names {
+			err := be.AddList(ctx, &backend.List{Id: uuid.New().String(), BoardId: b.Id, Name: list, Pos: float64(i)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.SetBackend(be)
+	return printBoard(c, ctx, b)
+}
+
+func List(c *cli.Context) error {
+	if _, err := cmdutil.Args(c); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	boards, err := be.ListBoards(ctx)
+	if err != nil {
+		return err
+	}
+	out := make([]board, 0, len(boards))
+	rows := make([][]string, 0, len(boards))
+	for _, b := range boards {
+		b.SetBackend(be)
+		printed, err := newBoard(ctx, b)
+		if err != nil {
+			return err
+		}
+		out = append(out, printed)
+		rows = append(rows, printed.row())
+	}
+	return store.Print(c, out, header, rows)
+}
+
+func Rename(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME", "NEW_NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	b, err := cmdutil.Board(ctx, be, args[0])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[1])
+	if err := unused(ctx, be, name); err != nil {
+		return err
+	}
+	b.Name = name
+	if err := b.Update(ctx); err != nil {
+		return err
+	}
+	return printBoard(c, ctx, b)
+}
+
+func Remove(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	b, err := cmdutil.Board(ctx, be, args[0])
+	if err != nil {
+		return err
+	}
+	if !forceVar {
+		printed, err := newBoard(ctx, b)
+		if err != nil {
+			return err
+		}
+		if printed.Cards > 0 {
+			return fmt.Errorf("board %q has %d cards, use --force to delete them with it: %w",
+				b.Name, printed.Cards, backend.ErrConflict)
+		}
+	}
+	return b.Delete(ctx)
+}
+
+func Clone(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME", "NEW_NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	b, err := cmdutil.Board(ctx, be, args[0])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[1])
+	if err := unused(ctx, be, name); err != nil {
+		return err
+	}
+	clone, err := b.Clone(ctx, name)
+	if err != nil {
+		return err
+	}
+	return printBoard(c, ctx, clone)
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "board",
+		Usage: "manage boards from scripts",
+		Description: "Boards are addressed by name.\n" +
+			"Exits with 2 on invalid arguments, 3 when something is not found and 4 on conflicts.",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "create a board with the default lists or --columns",
+				ArgsUsage: "NAME",
+				Flags:     createFlags,
+				Action:    cmdutil.Action(Create),
+			},
+			{
+				Name:    "ls",
+				Aliases: []string{"list"},
+				Usage:   "list the boards",
+				Flags:   store.Flags(),
+				Action:  cmdutil.Action(List),
+			},
+			{
+				Name:      "rename",
+				Usage:     "rename a board",
+				ArgsUsage: "NAME NEW_NAME",
+				Flags:     store.Flags(),
+				Action:    cmdutil.Action(Rename),
+			},
+			{
+				Name:      "rm",
+				Usage:     "delete a board with its lists and cards",
+				ArgsUsage: "NAME",
+				Flags:     rmFlags,
+				Action:    cmdutil.Action(Remove),
+			},
+			{
+				Name:      "clone",
+				Usage:     "copy a board with its lists and cards",
+				ArgsUsage: "NAME NEW_NAME",
+				Flags:     store.Flags(),
+				Action:    cmdutil.Action(Clone),
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package board
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	be, err := cmdutil.Open("bolt", cmdutil.MemoryDB)
+	testutil.Ok(t, "open", err)
+	testutil.Reset(t, be)
+	steps := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"create", "--columns", "Todo, Done", " Sprint "}, 0, "Sprint  Todo,Done"},
+		{[]string{"create", "Sprint"}, cmdutil.ExitConflict, "already exists"},
+		{[]string{"create", "--columns", "a,,b", "X"}, cmdutil.ExitUsage, "empty list name"},
+		{[]string{"create", "--columns", "a,A", "X"}, cmdutil.ExitUsage, "given twice"},
+		{[]string{"create"}, cmdutil.ExitUsage, "expects 1 argument"},
+		{[]string{"create", "--format", "yaml", "X"}, cmdutil.ExitUsage, "unknown format"},
+		{[]string{"create", " "}, cmdutil.ExitUsage, "board name is empty"},
+		{[]string{"create", "Main"}, 0, "TODO,READY TO DEVELOPMENT,DOING,TESTING,DONE"},
+		{[]string{"rename", "Missing", "Other"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"rename", "Sprint", "Main"}, cmdutil.ExitConflict, "already exists"},
+		{[]string{"rename", "Sprint", " sprint "}, 0, "sprint  Todo,Done"},
+		{[]string{"clone", "sprint", "Copy"}, 0, "Copy  Todo,Done"},
+		{[]string{"clone", "sprint", "Copy"}, cmdutil.ExitConflict, "already exists"},
+		{[]string{"ls"}, 0, "Copy"},
+		{[]string{"ls", "--format", "json"}, 0, `"Name": "sprint"`},
+	}
+	for _, s := range steps {
+		args := append([]string{s.args[0], "--db", cmdutil.MemoryDB}, s.args[1:]...)
+		out, code := testutil.Command(t, Command(), args...)
+		if code != s.code || !strings.Contains(out, s.out) {
+			t.Fatalf("%v: want %d with %q, got %d with %q", s.args, s.code, s.out, code, out)
+		}
+	}
+	out, _ := testutil.Command(t, Command(), "ls", "--db", cmdutil.MemoryDB)
+	if strings.Contains(out, "X") {
+		t.Fatalf("failed create left a board behind:\n%s", out)
+	}
+
+	board, err := cmdutil.Board(ctx, be, "sprint")
+	testutil.Ok(t, "board", err)
+	list, err := cmdutil.List(ctx, board, "todo")
+	testutil.Ok(t, "list", err)
+	testutil.Ok(t, "add card", list.AddCards(ctx, &backend.Card{Name: "card"}))
+	for _, s := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"rm", "sprint"}, cmdutil.ExitConflict},
+		{[]string{"rm", "--force", "sprint"}, 0},
+		{[]string{"rm", "sprint"}, cmdutil.ExitNotFound},
+		{[]string{"rm", "Copy"}, 0},
+	} {
+		args := append([]string{s.args[0], "--db", cmdutil.MemoryDB}, s.args[1:]...)
+		if out, code := testutil.Command(t, Command(), args...); code != s.code {
+			t.Fatalf("%v: want %d, got %d with %q", s.args, s.code, code, out)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package card
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/query"
+)
+
+var (
+	boardVar       string
+	listVar        string
+	queryVar       string
+	nameVar        string
+	descriptionVar string
+	valueVar       int
+	effortVar      int
+	workVar        int
+
+	store       cmdutil.Store
+	commonFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "board",
+			Aliases:     []string{"b"},
+			Usage:       "board name",
+			Destination: &boardVar,
+			Value:       "Main Board",
+		},
+	}, store.Flags()...)
+	descriptionFlag = &cli.StringFlag{
+		Name:        "description",
+		Usage:       "card description in Markdown",
+		Destination: &descriptionVar,
+	}
+	valueFlag = &cli.IntFlag{
+		Name:        "value",
+		Usage:       "business value",
+		Destination: &valueVar,
+	}
+	effortFlag = &cli.IntFlag{
+		Name:        "effort",
+		Usage:       "estimated effort",
+		Destination: &effortVar,
+	}
+	addFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "list",
+			Aliases:     []string{"l"},
+			Usage:       "list to add the card to, the first list by default",
+			Destination: &listVar,
+		},
+		descriptionFlag, valueFlag, effortFlag,
+	}, commonFlags...)
+	listFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "list",
+			Aliases:     []string{"l"},
+			Usage:       "only show the cards of this list",
+			Destination: &listVar,
+		},
+		&cli.StringFlag{
+			Name:        "query",
+			Aliases:     []string{"q"},
+			Usage:       "only show the cards matching this search, as typed after / in the board",
+			Destination: &queryVar,
+		},
+	}, commonFlags...)
+	editFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "name",
+			Usage:       "card name",
+			Destination: &nameVar,
+		},
+		descriptionFlag, valueFlag, effortFlag,
+		&cli.IntFlag{
+			Name:        "work",
+			Usage:       "work spent",
+			Destination: &workVar,
+		},
+	}, commonFlags...)
+)
+
+// withLabel returns flags with a new --label flag, as a slice flag keeps
+// the values it parsed for the next run of the command.
+func withLabel(flags []cli.Flag, usage string) []cli.Flag {
+	label := &cli.StringSliceFlag{Name: "label", Usage: usage}
+	return append([]cli.Flag{label}, flags...)
+}
+
+// card is a card as it is printed, with its list by name.
+type card struct {
+	Id, List, Name, Description string
+	Value, Effort, Work         int
+	Labels                      []string
+	LastUpdate                  time.Time
+}
+
+func newCard(c *backend.Card, list *backend.List) card {
+	out := card{
+		Id: c.Id, List: list.Name, Name: c.Name, Description: c.Description,
+		Value: c.Value, Effort: c.Effort, Work: c.Work, LastUpdate: c.LastUpdate,
+		Labels: []string{},
+	}
+	for _, l := range c.Labels {
+		out.Labels = append(out.Labels, l.Name)
+	}
+	return out
+}
+
+// open returns the board named by the flags.
+func open(ctx context.Context) (*backend.Board, error) {
+	be, err := store.Open()
+	if err != nil {
+		return nil, err
+	}
+	return cmdutil.Board(ctx, be, boardVar)
+}
+
+// labels returns the labels given with --label.
+func labels(c *cli.Context, board *backend.Board) ([]backend.Label, error) {
+	var out []backend.Label
+	for _, name := range c.StringSlice("label") {
+		// An empty --label leaves the card without labels.
+		if name == "" {
+			continue
+		}
+		label, ok := board.Label(name)
+		if !ok {
+			return nil, fmt.Errorf("label %q is not in the palette of board %q: %w", name, board.Name, backend.ErrInvalid)
+		}
+		out = append(out, label)
+	}
+	return out, nil
+}
+
+func printCards(c *cli.Context, cards []card) error {
+	rows := make([][]string, 0, len(cards))
+	for _, card := range cards {
+		rows = append(rows, []string{
+			backend.ShortId(card.Id), card.List, card.Name,
+			strconv.Itoa(card.Value), strconv.Itoa(card.Effort), strconv.Itoa(card.Work),
+			strings.Join(card.Labels, ","),
+		})
+	}
+	header := []string{"ID", "LIST", "NAME", "VALUE", "EFFORT", "WORK", "LABELS"}
+	return store.Print(c, cards, header, rows)
+}
+
+// printOne prints a single card in full.
+func printOne(c *cli.Context, card card) error {
+	rows := [][]string{
+		{"ID", card.Id},
+		{"List", card.List},
+		{"Name", card.Name},
+		{"Value", strconv.Itoa(card.Value)},
+		{"Effort", strconv.Itoa(card.Effort)},
+		{"Work", strconv.Itoa(card.Work)},
+		{"Labels", strings.Join(card.Labels, ",")},
+		{"Updated", card.LastUpdate.Format(time.RFC3339)},
+	}
+	if err := store.Print(c, card, nil, rows); err != nil {
+		return err
+	}
+	if store.Format == cmdutil.FormatTable && card.Description != "" {
+		fmt.Fprintf(c.App.Writer, "\n%s\n", strings.TrimRight(card.Description, "\n"))
+	}
+	return nil
+}
+
+func Add(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME")
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[0])
+	if name == "" {
+		return fmt.Errorf("card name is empty: %w", cmdutil.ErrUsage)
+	}
+	if valueVar < 0 || effortVar < 0 {
+		return fmt.Errorf("value and effort cannot be negative: %w", cmdutil.ErrUsage)
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	var list *backend.List
+	if listVar != "" {
+		list, err = cmdutil.List(ctx, board, listVar)
+	} else {
+		list, err = firstList(ctx, board)
+	}
+	if err != nil {
+		return err
+	}
+	cardLabels, err := labels(c, board)
+	if err != nil {
+		return err
+	}
+	added := &backend.Card{
+		Id:          uuid.New().String(),
+		Name:        name,
+		Description: descriptionVar,
+		Value:       valueVar,
+		Effort:      effortVar,
+		Labels:      cardLabels,
+	}
+	added.SetBackend(board.GetBackend())
+	if err := list.AddCards(ctx, added); err != nil {
+		return err
+	}
+	return printOne(c, newCard(added, list))
+}
+
+func firstList(ctx context.Context, board *backend.Board) (*backend.List, error) {
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("board %q has no lists: %w", board.Name, backend.ErrNotFound)
+	}
+	lists[0].SetBackend(board.GetBackend())
+	return lists[0], nil
+}
+
+func List(c *cli.Context) error {
+	if _, err := cmdutil.Args(c); err != nil {
+		return err
+	}
+	q, err := query.Parse(queryVar)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return err
+	}
+	if listVar != "" {
+		list, err := cmdutil.List(ctx, board, listVar)
+		if err != nil {
+			return err
+		}
+		lists = []*backend.List{list}
+	}
+	cards := []card{}
+	for _, list := range lists {
+		list.SetBackend(board.GetBackend())
+		listCards, err := list.Cards(ctx)
+		if err != nil {
+			return err
+		}
+		for _, lc := range listCards {
+			if q.Match(lc, list) {
+				cards = append(cards, newCard(lc, list))
+			}
+		}
+	}
+	return printCards(c, cards)
+}
+
+func Show(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "ID")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	found, list, err := cmdutil.Card(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	return printOne(c, newCard(found, list))
+}
+
+func Edit(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "ID")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	found, list, err := cmdutil.Card(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	if c.IsSet("name") {
+		found.Name = strings.TrimSpace(nameVar)
+		if found.Name == "" {
+			return fmt.Errorf("card name is empty: %w", cmdutil.ErrUsage)
+		}
+	}
+	if c.IsSet("description") {
+		found.Description = descriptionVar
+	}
+	for flag, field := range map[string]*int{"value": &found.Value, "effort": &found.Effort, "work": &found.Work} {
+		if !c.IsSet(flag) {
+			continue
+		}
+		n := c.Int(flag)
+		if n < 0 {
+			return fmt.Errorf("%s is negative: %w", flag, cmdutil.ErrUsage)
+		}
+		*field = n
+	}
+	if c.IsSet("label") {
+		if found.Labels, err = labels(c, board); err != nil {
+			return err
+		}
+	}
+	if err := found.Update(ctx); err != nil {
+		return err
+	}
+	return printOne(c, newCard(found, list))
+}
+
+func Move(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "ID", "LIST")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	found, _, err := cmdutil.Card(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	target, err := cmdutil.List(ctx, board, args[1])
+	if err != nil {
+		return err
+	}
+	if err := found.Move(ctx, target); err != nil {
+		return err
+	}
+	return printOne(c, newCard(found, target))
+}
+
+func Remove(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "ID")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	found, _, err := cmdutil.Card(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	return board.GetBackend().DeleteCard(ctx, found.Id)
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "card",
+		Usage: "manage cards from scripts",
+		Description: "Cards are addressed by a prefix of their id, boards and lists by name.\n" +
+			"Exits with 2 on invalid arguments, 3 when something is not found and 4 on conflicts.",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "add a card",
+				ArgsUsage: "NAME",
+				Flags:     withLabel(addFlags, "label from the board palette, may be repeated"),
+				Action:    cmdutil.Action(Add),
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Usage:   "list the cards of the board",
+				Flags:   listFlags,
+				Action:  cmdutil.Action(List),
+			},
+			{
+				Name:      "show",
+				Usage:     "show a card",
+				ArgsUsage: "ID",
+				Flags:     commonFlags,
+				Action:    cmdutil.Action(Show),
+			},
+			{
+				Name:      "edit",
+				Usage:     "change the fields of a card given as flags",
+				ArgsUsage: "ID",
+				Flags:     withLabel(editFlags, "label from the board palette, may be repeated; replaces the labels of the card, which an empty label clears"),
+				Action:    cmdutil.Action(Edit),
+			},
+			{
+				Name:      "move",
+				Aliases:   []string{"mv"},
+				Usage:     "move a card to another list",
+				ArgsUsage: "ID LIST",
+				Flags:     commonFlags,
+				Action:    cmdutil.Action(Move),
+			},
+			{
+				Name:      "rm",
+				Usage:     "delete a card",
+				ArgsUsage: "ID",
+				Flags:     commonFlags,
+				Action:    cmdutil.Action(Remove),
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	be, err := cmdutil.Open("bolt", cmdutil.MemoryDB)
+	testutil.Ok(t, "open", err)
+	testutil.Reset(t, be)
+	board := &backend.Board{Name: "Sprint", Labels: []backend.Label{{Name: "bug", Color: "red"}}}
+	testutil.Ok(t, "add board", be.AddBoard(ctx, board))
+	board.SetBackend(be)
+	todo := &backend.List{Name: "Todo"}
+	testutil.Ok(t, "add lists", board.AddLists(ctx, todo, &backend.List{Name: "Done", Pos: 1}))
+	todo.SetBackend(be)
+	testutil.Ok(t, "add cards", todo.AddCards(ctx, &backend.Card{Id: "abc1", Name: "a"}, &backend.Card{Id: "abc2", Name: "b"}))
+
+	run := func(args ...string) (string, int) {
+		t.Helper()
+		flags := []string{"--db", cmdutil.MemoryDB, "--format", "json"}
+		if !strings.HasPrefix(args[1], "--board") {
+			flags = append(flags, "--board", "Sprint")
+		}
+		return testutil.Command(t, Command(), append(append(args[:1:1], flags...), args[1:]...)...)
+	}
+	out, code := run("add", "--list", "todo", "--value", "3", "--effort", "2", "--label", "bug", "--description", "Only on **Firefox**", "Fix login")
+	var added struct{ Id string }
+	if code != 0 || json.Unmarshal([]byte(out), &added) != nil {
+		t.Fatalf("add: %d %s", code, out)
+	}
+	id := added.Id[:6]
+
+	steps := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"add", " "}, cmdutil.ExitUsage, "card name is empty"},
+		{[]string{"add", "--value", "-1", "x"}, cmdutil.ExitUsage, "cannot be negative"},
+		{[]string{"add", "--label", "nope", "x"}, cmdutil.ExitUsage, `label "nope" is not in the palette`},
+		{[]string{"add", "--list", "Missing", "x"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"add", "--board=Missing", "x"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"add", "--list", "done", "Plain"}, 0, `"Labels": []`},
+		{[]string{"show", id}, 0, `"Labels": [
+    "bug"
+  ]`},
+		{[]string{"show", "zzz"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"show", "abc"}, cmdutil.ExitUsage, "matches several cards"},
+		{[]string{"show", ""}, cmdutil.ExitUsage, "empty card id"},
+		{[]string{"list", "--query", "label:bug"}, 0, `"Name": "Fix login"`},
+		{[]string{"list", "--query", "value>"}, cmdutil.ExitUsage, ""},
+		{[]string{"list", "--list", "Missing"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"edit", "--work", "5", id}, 0, `"Work": 5`},
+		{[]string{"edit", "--work", "-1", id}, cmdutil.ExitUsage, "work is negative"},
+		{[]string{"edit", "--name", " ", id}, cmdutil.ExitUsage, "card name is empty"},
+		{[]string{"edit", "--label", "nope", id}, cmdutil.ExitUsage, "not in the palette"},
+		{[]string{"edit", id}, 0, `"Value": 3`},
+		{[]string{"edit", "--label", "", id}, 0, `"Labels": []`},
+		{[]string{"edit", "--label", "bug", id}, 0, `"bug"`},
+		{[]string{"move", id, "done"}, 0, `"List": "Done"`},
+		{[]string{"move", id, "Missing"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"move", id}, cmdutil.ExitUsage, "expects 2 argument(s)"},
+		{[]string{"rm", id}, 0, ""},
+		{[]string{"rm", id}, cmdutil.ExitNotFound, "not found"},
+	}
+	for _, s := range steps {
+		out, code := run(s.args...)
+		if code != s.code || !strings.Contains(out, s.out) {
+			t.Fatalf("%v: want %d with %q, got %d with %q", s.args, s.code, s.out, code, out)
+		}
+	}
+
+	out, code = testutil.Command(t, Command(), "show", "--db", cmdutil.MemoryDB, "--board", "Sprint", "abc1")
+	if code != 0 || !strings.HasPrefix(out, "ID ") || !strings.Contains(out, "Name     a\n") {
+		t.Fatalf("unexpected table %d:\n%s", code, out)
+	}
+	out, _ = run("list", "--query", "label:bug")
+	if !strings.HasPrefix(strings.TrimSpace(out), "[") || strings.Contains(out, "Plain") {
+		t.Fatalf("unexpected cards:\n%s", out)
+	}
+}
@@ -3,7 +3,12 @@ package cmd
 import (
 	"github.com/urfave/cli/v2"
 
+	"github.com/twistedogic/orga/cmd/board"
+	"github.com/twistedogic/orga/cmd/card"
+	"github.com/twistedogic/orga/cmd/db"
+	"github.com/twistedogic/orga/cmd/list"
 	"github.com/twistedogic/orga/cmd/run"
+	"github.com/twistedogic/orga/cmd/transfer"
 )
 
 func App() *cli.App {
@@ -12,6 +17,12 @@ func App() *cli.App {
 		Usage: "Local Kanban board for agile task management",
 		Commands: []*cli.Command{
 			run.Command(),
+			db.Command(),
+			board.Command(),
+			list.Command(),
+			card.Command(),
+			transfer.ExportCommand(),
+			transfer.ImportCommand(),
 		},
 	}
 }
@@ -0,0 +1,269 @@
+// Package cmdutil holds what the orga commands share: opening the storage
+// backend, looking up boards, lists and cards by name, printing results and
+// turning errors into exit codes.
+package cmdutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/pkg/archive"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/bolt"
+	"github.com/twistedogic/orga/pkg/backend/fs"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+	"github.com/twistedogic/orga/pkg/backend/sqlite"
+	"github.com/twistedogic/orga/pkg/query"
+)
+
+// MemoryDB is the database path that opens a throwaway database. bolt has
+// no in-memory mode, so with the bolt backend it opens an in-memory backend
+// that every command run in the process shares. sqlite opens its own
+// in-memory database, and fs refuses it. Either way it is gone on exit.
+const MemoryDB = ":memory:"
+
+var (
+	memoryOnce sync.Once
+	memoryDB   *memory.Backend
+)
+
+// Exit codes of the non-interactive commands.
+const (
+	ExitError    = 1
+	ExitUsage    = 2
+	ExitNotFound = 3
+	ExitConflict = 4
+)
+
+var (
+	// ErrUsage marks errors in the arguments of a command.
+	ErrUsage = errors.New("invalid arguments")
+	// ErrAmbiguous is returned when an id prefix matches several cards.
+	ErrAmbiguous = errors.New("ambiguous")
+)
+
+// Open returns the backend of kind stored at path.
+func Open(kind, path string) (backend.Backend, error) {
+	switch kind {
+	case "bolt":
+		if path == MemoryDB {
+			memoryOnce.Do(func() { memoryDB = memory.New() })
+			return memoryDB, nil
+		}
+		b, err := bolt.New(path)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "sqlite":
+		b, err := sqlite.New(path)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "fs":
+		if path == MemoryDB {
+			return nil, fmt.Errorf("the fs backend cannot keep boards in %s: %w", MemoryDB, ErrUsage)
+		}
+		b, err := fs.New(path)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q: %w", kind, ErrUsage)
+	}
+}
+
+// Store is the storage and output format chosen by the flags shared by the
+// non-interactive commands.
+type Store struct {
+	DB, Backend, Format string
+}
+
+// Flags returns the flags setting s.
+func (s *Store) Flags() []cli.Flag {
+	return append(s.DBFlags(), &cli.StringFlag{
+		Name:        "format",
+		Aliases:     []string{"f"},
+		Usage:       "output format, table or json",
+		Destination: &s.Format,
+		Value:       FormatTable,
+	})
+}
+
+// DBFlags returns the flags choosing the storage of s, for commands that
+// print no table.
+func (s *Store) DBFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "db",
+			Aliases:     []string{"d"},
+			Usage:       "database file path",
+			Destination: &s.DB,
+			Value:       "orga.db",
+		},
+		&cli.StringFlag{
+			Name:        "backend",
+			Usage:       "storage backend, one of bolt, sqlite or fs",
+			Destination: &s.Backend,
+			Value:       "bolt",
+		},
+	}
+}
+
+// Open checks the output format, if there is one, so that nothing is
+// changed before a usage error, and opens the backend.
+func (s *Store) Open() (backend.Backend, error) {
+	if s.Format != "" {
+		if err := CheckFormat(s.Format); err != nil {
+			return nil, err
+		}
+	}
+	return Open(s.Backend, s.DB)
+}
+
+// Print writes v or rows in the output format of s.
+func (s *Store) Print(c *cli.Context, v any, header []string, rows [][]string) error {
+	return Print(c.App.Writer, s.Format, v, header, rows)
+}
+
+// Board returns the board called name.
+func Board(ctx context.Context, be backend.Backend, name string) (*backend.Board, error) {
+	boards, err := be.ListBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range boards {
+		if b.Name == name {
+			b.SetBackend(be)
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("board %q: %w", name, backend.ErrNotFound)
+}
+
+// List returns the list of board called name, ignoring case.
+func List(ctx context.Context, board *backend.Board, name string) (*backend.List, error) {
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		if strings.EqualFold(l.Name, name) {
+			l.SetBackend(board.GetBackend())
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("list %q on board %q: %w", name, board.Name, backend.ErrNotFound)
+}
+
+// Card returns the card of board whose id starts with prefix, and its list.
+func Card(ctx context.Context, board *backend.Board, prefix string) (*backend.Card, *backend.List, error) {
+	if prefix == "" {
+		return nil, nil, fmt.Errorf("empty card id: %w", ErrUsage)
+	}
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var card *backend.Card
+	var list *backend.List
+	for _, l := range lists {
+		l.SetBackend(board.GetBackend())
+		cards, err := l.Cards(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range cards {
+			if !strings.HasPrefix(c.Id, prefix) {
+				continue
+			}
+			if card != nil {
+				return nil, nil, fmt.Errorf("card id %q matches several cards: %w", prefix, ErrAmbiguous)
+			}
+			card, list = c, l
+		}
+	}
+	if card == nil {
+		return nil, nil, fmt.Errorf("card %q on board %q: %w", prefix, board.Name, backend.ErrNotFound)
+	}
+	card.SetBackend(board.GetBackend())
+	return card, list, nil
+}
+
+// Action runs fn, turning the error it returns into an exit code.
+func Action(fn cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		return Exit(fn(c))
+	}
+}
+
+// Exit wraps err with the exit code matching it.
+func Exit(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, backend.ErrNotFound):
+		return cli.Exit(err, ExitNotFound)
+	case errors.Is(err, backend.ErrConflict):
+		return cli.Exit(err, ExitConflict)
+	case errors.Is(err, ErrUsage), errors.Is(err, ErrAmbiguous),
+		errors.Is(err, backend.ErrInvalid), errors.Is(err, query.ErrSyntax),
+		errors.Is(err, archive.ErrVersion):
+		return cli.Exit(err, ExitUsage)
+	}
+	return cli.Exit(err, ExitError)
+}
+
+// Args returns the n arguments of c, or a usage error naming them.
+func Args(c *cli.Context, names ...string) ([]string, error) {
+	if c.NArg() != len(names) {
+		return nil, fmt.Errorf("%s expects %d argument(s): %s: %w",
+			c.Command.Name, len(names), strings.Join(names, " "), ErrUsage)
+	}
+	return c.Args().Slice(), nil
+}
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// CheckFormat returns a usage error unless format is a known output format.
+func CheckFormat(format string) error {
+	if format != FormatTable && format != FormatJSON {
+		return fmt.Errorf("unknown format %q, want %s or %s: %w", format, FormatTable, FormatJSON, ErrUsage)
+	}
+	return nil
+}
+
+// Print writes v as indented JSON, or header and rows as an aligned table,
+// depending on format. The table has no header line if header is nil.
+func Print(w io.Writer, format string, v any, header []string, rows [][]string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		if header != nil {
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+	return CheckFormat(format)
+}
@@ -0,0 +1,83 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+	"github.com/twistedogic/orga/pkg/backend/sqlite"
+)
+
+func TestLookup(t *testing.T) {
+	ctx := context.Background()
+	be := memory.New()
+	if err := be.AddBoard(ctx, &backend.Board{Name: "Main"}); err != nil {
+		t.Fatal(err)
+	}
+	board, err := Board(ctx, be, "Main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := board.AddLists(ctx, &backend.List{Name: "TODO"}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := List(ctx, board, "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddCards(ctx, &backend.Card{Id: "abc1"}, &backend.Card{Id: "abd2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	card, _, err := Card(ctx, board, "abc")
+	if err != nil || card.Id != "abc1" {
+		t.Fatalf("want abc1, got %v %v", card, err)
+	}
+	for prefix, want := range map[string]error{"ab": ErrAmbiguous, "x": backend.ErrNotFound, "": ErrUsage} {
+		if _, _, err := Card(ctx, board, prefix); !errors.Is(err, want) {
+			t.Errorf("%q: want %v, got %v", prefix, want, err)
+		}
+	}
+	if _, err := Board(ctx, be, "Other"); !errors.Is(err, backend.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	be, err := Open("bolt", MemoryDB)
+	if _, ok := be.(*memory.Backend); err != nil || !ok {
+		t.Fatalf("bolt: want the memory backend, got %T %v", be, err)
+	}
+	be, err = Open("sqlite", MemoryDB)
+	if _, ok := be.(*sqlite.Backend); err != nil || !ok {
+		t.Fatalf("sqlite: want an in-memory sqlite backend, got %T %v", be, err)
+	}
+	for _, kind := range []string{"fs", "other"} {
+		if _, err := Open(kind, MemoryDB); !errors.Is(err, ErrUsage) {
+			t.Errorf("%s: want ErrUsage, got %v", kind, err)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	cases := map[error]int{
+		fmt.Errorf("x: %w", backend.ErrNotFound): ExitNotFound,
+		fmt.Errorf("x: %w", backend.ErrConflict): ExitConflict,
+		fmt.Errorf("x: %w", ErrAmbiguous):        ExitUsage,
+		errors.New("disk full"):                  ExitError,
+	}
+	for err, want := range cases {
+		var coder cli.ExitCoder
+		if !errors.As(Exit(err), &coder) || coder.ExitCode() != want {
+			t.Errorf("%v: want exit code %d", err, want)
+		}
+	}
+	if Exit(nil) != nil {
+		t.Fatal("nil error should exit cleanly")
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/pkg/backend/bolt"
+)
+
+var (
+	dbVar        string
+	dryRunVar    bool
+	migrateFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "db",
+			Aliases:     []string{"d"},
+			Usage:       "database file path",
+			Destination: &dbVar,
+			Value:       "orga.db",
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "report pending migrations without applying them",
+			Destination: &dryRunVar,
+		},
+	}
+)
+
+func Migrate(ctx *cli.Context) error {
+	db, err := bolt.Open(dbVar)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	version, err := bolt.SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	results, err := bolt.Migrate(db, dryRunVar)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	w := ctx.App.Writer
+	if len(results) == 0 {
+		fmt.Fprintf(w, "%s is up to date at schema version %d\n", dbVar, version)
+		return nil
+	}
+	verb := "applied"
+	if dryRunVar {
+		verb = "would apply"
+	}
+	fmt.Fprintf(w, "%s: schema version %d, %s %d migration(s)\n", dbVar, version, verb, len(results))
+	for _, r := range results {
+		fmt.Fprintf(w, "  %d: %s (%d record(s) changed)\n", r.Version, r.Description, r.Changed)
+	}
+	return nil
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "db",
+		Usage: "manage the bolt database file",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "migrate",
+				Usage:  "upgrade the database to the latest schema version",
+				Flags:  migrateFlags,
+				Action: Migrate,
+			},
+		},
+	}
+}
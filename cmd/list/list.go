@@ -0,0 +1,339 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+var (
+	store       cmdutil.Store
+	boardVar    string
+	atVar       int
+	moveToVar   string
+	forceVar    bool
+	commonFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "board",
+			Aliases:     []string{"b"},
+			Usage:       "board name",
+			Destination: &boardVar,
+			Value:       "Main Board",
+		},
+	}, store.Flags()...)
+	addFlags = append([]cli.Flag{
+		&cli.IntFlag{
+			Name:        "at",
+			Usage:       "position of the new list counting from 1, the end by default",
+			Destination: &atVar,
+		},
+	}, commonFlags...)
+	rmFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "move-to",
+			Usage:       "list to move the cards to before deleting",
+			Destination: &moveToVar,
+		},
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "delete the cards of the list with it",
+			Destination: &forceVar,
+		},
+	}, commonFlags...)
+)
+
+// list is a list as it is printed, with its position counting from 1.
+type list struct {
+	Id, Name string
+	Position int
+	Order    string
+	Cards    int
+}
+
+var header = []string{"POSITION", "NAME", "ORDER", "CARDS", "ID"}
+
+func (l list) row() []string {
+	return []string{strconv.Itoa(l.Position), l.Name, l.Order, strconv.Itoa(l.Cards), l.Id}
+}
+
+// lists returns the lists of board as they are printed.
+func lists(ctx context.Context, board *backend.Board) ([]list, error) {
+	bl, err := board.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]list, 0, len(bl))
+	for i, l := range bl {
+		cards, err := board.GetBackend().ListCards(ctx, l.Id)
+		if err != nil {
+			return nil, err
+		}
+		order := "priority"
+		if l.Order == backend.ManualOrder {
+			order = string(l.Order)
+		}
+		out = append(out, list{Id: l.Id, Name: l.Name, Position: i + 1, Order: order, Cards: len(cards)})
+	}
+	return out, nil
+}
+
+// printList prints the list with id, as a JSON object rather than an array.
+func printList(c *cli.Context, ctx context.Context, board *backend.Board, id string) error {
+	all, err := lists(ctx, board)
+	if err != nil {
+		return err
+	}
+	for _, l := range all {
+		if l.Id == id {
+			return store.Print(c, l, header, [][]string{l.row()})
+		}
+	}
+	return fmt.Errorf("list %q: %w", id, backend.ErrNotFound)
+}
+
+func open(ctx context.Context) (*backend.Board, error) {
+	be, err := store.Open()
+	if err != nil {
+		return nil, err
+	}
+	return cmdutil.Board(ctx, be, boardVar)
+}
+
+// unused fails with backend.ErrConflict if board has a list called name,
+// as the commands address lists by name.
+func unused(ctx context.Context, board *backend.Board, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("list name is empty: %w", cmdutil.ErrUsage)
+	}
+	_, err := cmdutil.List(ctx, board, name)
+	if err == nil {
+		return fmt.Errorf("list %q already exists on board %q: %w", name, board.Name, backend.ErrConflict)
+	}
+	if errors.Is(err, backend.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// position checks a position counting from 1 among n lists and returns it
+// as an index.
+func position(p, n int) (int, error) {
+	if p < 1 || p > n {
+		return 0, fmt.Errorf("position %d is not between 1 and %d: %w", p, n, cmdutil.ErrUsage)
+	}
+	return p - 1, nil
+}
+
+func Add(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[0])
+	if err := unused(ctx, board, name); err != nil {
+		return err
+	}
+	existing, err := board.Lists(ctx)
+	if err != nil {
+		return err
+	}
+	index := len(existing)
+	if c.IsSet("at") {
+		if index, err = position(atVar, len(existing)+1); err != nil {
+			return err
+		}
+	}
+	added := &backend.List{Id: uuid.New().String(), Name: name}
+	if n := len(existing); n > 0 {
+		added.Pos = existing[n-1].Pos + 1
+	}
+	// The list is added and moved together so a failed move does not leave
+	// it at the end.
+	err = backend.RunTx(ctx, board.GetBackend(), func(be backend.Backend) error {
+		inTx := *board
+		inTx.SetBackend(be)
+		if err := inTx.AddLists(ctx, added); err != nil {
+			return err
+		}
+		if index < len(existing) {
+			return inTx.Reorder(ctx, added, index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return printList(c, ctx, board, added.Id)
+}
+
+func List(c *cli.Context) error {
+	if _, err := cmdutil.Args(c); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	all, err := lists(ctx, board)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(all))
+	for _, l := range all {
+		rows = append(rows, l.row())
+	}
+	return store.Print(c, all, header, rows)
+}
+
+func Rename(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME", "NEW_NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	l, err := cmdutil.List(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(args[1])
+	// Allow changing the case of a name.
+	if !strings.EqualFold(l.Name, name) {
+		if err := unused(ctx, board, name); err != nil {
+			return err
+		}
+	}
+	l.Name = name
+	if err := l.Update(ctx); err != nil {
+		return err
+	}
+	return printList(c, ctx, board, l.Id)
+}
+
+func Move(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME", "POSITION")
+	if err != nil {
+		return err
+	}
+	p, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("position %q is not a number: %w", args[1], cmdutil.ErrUsage)
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	l, err := cmdutil.List(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	existing, err := board.Lists(ctx)
+	if err != nil {
+		return err
+	}
+	index, err := position(p, len(existing))
+	if err != nil {
+		return err
+	}
+	if err := board.Reorder(ctx, l, index); err != nil {
+		return err
+	}
+	return printList(c, ctx, board, l.Id)
+}
+
+func Remove(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "NAME")
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	board, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	l, err := cmdutil.List(ctx, board, args[0])
+	if err != nil {
+		return err
+	}
+	if moveToVar != "" {
+		target, err := cmdutil.List(ctx, board, moveToVar)
+		if err != nil {
+			return err
+		}
+		return l.DeleteMovingCards(ctx, target)
+	}
+	if !forceVar {
+		cards, err := l.Cards(ctx)
+		if err != nil {
+			return err
+		}
+		if len(cards) > 0 {
+			return fmt.Errorf("list %q has %d cards, use --move-to or --force: %w",
+				l.Name, len(cards), backend.ErrConflict)
+		}
+	}
+	return l.Delete(ctx)
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "manage the lists of a board from scripts",
+		Description: "Lists are addressed by name, ignoring case, and positions count from 1.\n" +
+			"Exits with 2 on invalid arguments, 3 when something is not found and 4 on conflicts.",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "add a list",
+				ArgsUsage: "NAME",
+				Flags:     addFlags,
+				Action:    cmdutil.Action(Add),
+			},
+			{
+				Name:   "ls",
+				Usage:  "list the lists of the board in order",
+				Flags:  commonFlags,
+				Action: cmdutil.Action(List),
+			},
+			{
+				Name:      "rename",
+				Usage:     "rename a list",
+				ArgsUsage: "NAME NEW_NAME",
+				Flags:     commonFlags,
+				Action:    cmdutil.Action(Rename),
+			},
+			{
+				Name:      "mv",
+				Usage:     "move a list to another position",
+				ArgsUsage: "NAME POSITION",
+				Flags:     commonFlags,
+				Action:    cmdutil.Action(Move),
+			},
+			{
+				Name:      "rm",
+				Usage:     "delete a list, refusing if it has cards unless --move-to or --force is given",
+				ArgsUsage: "NAME",
+				Flags:     rmFlags,
+				Action:    cmdutil.Action(Remove),
+			},
+		},
+	}
+}
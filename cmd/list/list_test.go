@@ -0,0 +1,75 @@
+package list
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	be, err := cmdutil.Open("bolt", cmdutil.MemoryDB)
+	testutil.Ok(t, "open", err)
+	testutil.Reset(t, be)
+	board := &backend.Board{Name: "Sprint"}
+	testutil.Ok(t, "add board", be.AddBoard(ctx, board))
+	board.SetBackend(be)
+	todo := &backend.List{Name: "Todo"}
+	testutil.Ok(t, "add lists", board.AddLists(ctx, todo, &backend.List{Name: "Doing", Pos: 1}, &backend.List{Name: "Done", Pos: 2}))
+	todo.SetBackend(be)
+	testutil.Ok(t, "add card", todo.AddCards(ctx, &backend.Card{Name: "card"}))
+
+	steps := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"ls", "--board", "Missing"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"add", "--at", "0", "Review"}, cmdutil.ExitUsage, "not between 1 and 4"},
+		{[]string{"add", "--at", "5", "Review"}, cmdutil.ExitUsage, "not between 1 and 4"},
+		{[]string{"add", " done "}, cmdutil.ExitConflict, "already exists"},
+		{[]string{"add", " "}, cmdutil.ExitUsage, "list name is empty"},
+		{[]string{"add", "--at", "4", "Shipped"}, 0, `"Position": 4`},
+		{[]string{"add", "--at", "1", " Backlog "}, 0, `"Name": "Backlog"`},
+		{[]string{"rename", "todo", "TODO"}, 0, `"Name": "TODO"`},
+		{[]string{"rename", "doing", " done "}, cmdutil.ExitConflict, "already exists"},
+		{[]string{"rename", "doing", " "}, cmdutil.ExitUsage, "list name is empty"},
+		{[]string{"rename", "Missing", "Other"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"mv", "done", "0"}, cmdutil.ExitUsage, "not between 1 and 5"},
+		{[]string{"mv", "done", "6"}, cmdutil.ExitUsage, "not between 1 and 5"},
+		{[]string{"mv", "done", "last"}, cmdutil.ExitUsage, "not a number"},
+		{[]string{"mv", "done", "1"}, 0, `"Position": 1`},
+		{[]string{"mv", "done", "5"}, 0, `"Position": 5`},
+		{[]string{"rm", "todo"}, cmdutil.ExitConflict, "use --move-to or --force"},
+		{[]string{"rm", "--move-to", "Missing", "todo"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"rm", "--move-to", "todo", "todo"}, cmdutil.ExitUsage, "cannot take its own cards"},
+		{[]string{"rm", "--move-to", "doing", "todo"}, 0, ""},
+		{[]string{"rm", "doing"}, cmdutil.ExitConflict, "has 1 cards"},
+		{[]string{"rm", "--force", "doing"}, 0, ""},
+		{[]string{"rm", "doing"}, cmdutil.ExitNotFound, "not found"},
+		{[]string{"rm", "backlog"}, 0, ""},
+	}
+	for _, s := range steps {
+		args := append([]string{s.args[0], "--db", cmdutil.MemoryDB, "--format", "json"}, s.args[1:]...)
+		if s.args[1] != "--board" {
+			args = append(args[:1], append([]string{"--board", "Sprint"}, args[1:]...)...)
+		}
+		out, code := testutil.Command(t, Command(), args...)
+		if code != s.code || !strings.Contains(out, s.out) {
+			t.Fatalf("%v: want %d with %q, got %d with %q", s.args, s.code, s.out, code, out)
+		}
+	}
+
+	out, code := testutil.Command(t, Command(), "ls", "--db", cmdutil.MemoryDB, "--board", "Sprint")
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		names = append(names, strings.Fields(line)[1])
+	}
+	if code != 0 || strings.Join(names, ",") != "Shipped,Done" {
+		t.Fatalf("want lists Shipped,Done, got %d:\n%s", code, out)
+	}
+}
@@ -7,15 +7,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/urfave/cli/v2"
 
+	"github.com/twistedogic/orga/cmd/cmdutil"
 	"github.com/twistedogic/orga/pkg/backend"
-	"github.com/twistedogic/orga/pkg/backend/bolt"
+	"github.com/twistedogic/orga/pkg/undo"
 	"github.com/twistedogic/orga/pkg/view"
 )
 
 var (
-	boardVar string
-	dbVar    string
-	runFlags = []cli.Flag{
+	boardVar   string
+	dbVar      string
+	backendVar string
+	runFlags   = []cli.Flag{
 		&cli.StringFlag{
 			Name:        "board",
 			Aliases:     []string{"b"},
@@ -26,16 +28,22 @@ var (
 		&cli.StringFlag{
 			Name:        "db",
 			Aliases:     []string{"d"},
-			Usage:       "database file path",
+			Usage:       "database file path, or " + cmdutil.MemoryDB + " for a throwaway board",
 			Destination: &dbVar,
 			Value:       "orga.db",
 		},
+		&cli.StringFlag{
+			Name:        "backend",
+			Usage:       "storage backend, one of bolt, sqlite or fs",
+			Destination: &backendVar,
+			Value:       "bolt",
+		},
 	}
 )
 
 func Run(ctx *cli.Context) error {
 	// Initialize backend
-	backendInstance, err := bolt.New(dbVar)
+	backendInstance, err := cmdutil.Open(backendVar, dbVar)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -73,6 +81,14 @@ func Run(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize view: %w", err)
 	}
+	if dbVar != cmdutil.MemoryDB {
+		// Keep the undo history next to the database so it survives a restart.
+		history, err := undo.Open(dbVar + ".undo")
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+		v.SetHistory(history)
+	}
 
 	return v.Run()
 }
@@ -88,4 +104,4 @@ func Command() *cli.Command {
 		Flags:  runFlags,
 		Action: Run,
 	}
-}
\ No newline at end of file
+}
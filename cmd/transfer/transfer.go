@@ -0,0 +1,239 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/cmd/cmdutil"
+	"github.com/twistedogic/orga/pkg/archive"
+	"github.com/twistedogic/orga/pkg/trello"
+)
+
+var (
+	store       cmdutil.Store
+	boardVar    string
+	outVar      string
+	mergeVar    bool
+	copyVar     bool
+	nameVar     string
+	fromVar     string
+	toVar       string
+	dryRunVar   bool
+	exportFlags = append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "board",
+			Aliases:     []string{"b"},
+			Usage:       "board name to export",
+			Destination: &boardVar,
+			Value:       "Main Board",
+		},
+		&cli.StringFlag{
+			Name:        "out",
+			Aliases:     []string{"o"},
+			Usage:       "file to write, standard output if empty or -",
+			Destination: &outVar,
+		},
+		&cli.StringFlag{
+			Name:        "to",
+			Usage:       "format of the file, orga for the whole board or csv for its cards",
+			Destination: &toVar,
+			Value:       "orga",
+		},
+	}, store.DBFlags()...)
+	importFlags = append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:        "merge",
+			Usage:       "update the board with the same id if it exists, adding what is missing",
+			Destination: &mergeVar,
+		},
+		&cli.BoolFlag{
+			Name:        "copy",
+			Usage:       "import as a new board with new ids",
+			Destination: &copyVar,
+		},
+		&cli.StringFlag{
+			Name:        "name",
+			Usage:       "name of the imported board, the name in the file if empty",
+			Destination: &nameVar,
+		},
+		&cli.StringFlag{
+			Name:        "from",
+			Usage:       "format of the file, orga or csv for files written by export, or trello for Trello board exports",
+			Destination: &fromVar,
+			Value:       "orga",
+		},
+		&cli.StringFlag{
+			Name:        "board",
+			Aliases:     []string{"b"},
+			Usage:       "board to update from a CSV file",
+			Destination: &boardVar,
+			Value:       "Main Board",
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "report what a CSV file would change without writing it",
+			Destination: &dryRunVar,
+		},
+	}, store.DBFlags()...)
+)
+
+func Export(c *cli.Context) error {
+	if _, err := cmdutil.Args(c); err != nil {
+		return err
+	}
+	write := archive.Write
+	switch toVar {
+	case "orga":
+	case "csv":
+		write = archive.WriteCSV
+	default:
+		return fmt.Errorf("unknown format %q, want orga or csv: %w", toVar, cmdutil.ErrUsage)
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	board, err := cmdutil.Board(ctx, be, boardVar)
+	if err != nil {
+		return err
+	}
+	doc, err := archive.Export(ctx, board)
+	if err != nil {
+		return err
+	}
+	if outVar == "" || outVar == "-" {
+		return write(c.App.Writer, doc)
+	}
+	f, err := os.Create(outVar)
+	if err != nil {
+		return err
+	}
+	if err := write(f, doc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func Import(c *cli.Context) error {
+	args, err := cmdutil.Args(c, "FILE")
+	if err != nil {
+		return err
+	}
+	mode := archive.Create
+	switch {
+	case mergeVar && copyVar:
+		return fmt.Errorf("--merge and --copy cannot be combined: %w", cmdutil.ErrUsage)
+	case mergeVar:
+		mode = archive.Merge
+	case copyVar:
+		mode = archive.Copy
+	}
+	switch {
+	case fromVar != "orga" && fromVar != "trello" && fromVar != "csv":
+		return fmt.Errorf("unknown format %q, want orga, csv or trello: %w", fromVar, cmdutil.ErrUsage)
+	case fromVar == "csv" && (mergeVar || copyVar || nameVar != ""):
+		return fmt.Errorf("--merge, --copy and --name do not apply to CSV files: %w", cmdutil.ErrUsage)
+	case fromVar != "csv" && (dryRunVar || c.IsSet("board")):
+		return fmt.Errorf("--board and --dry-run only apply to CSV files: %w", cmdutil.ErrUsage)
+	}
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if fromVar == "csv" {
+		return importCSV(c, r, args[0])
+	}
+	var doc *archive.Document
+	var report *trello.Report
+	if fromVar == "trello" {
+		doc, report, err = trello.Parse(r)
+	} else {
+		doc, err = archive.Read(r)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", args[0], err)
+	}
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	res, err := archive.Import(context.Background(), be, doc, mode, nameVar)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "Imported board %q: %d list(s) added, %d updated; %d card(s) added, %d updated\n",
+		res.Board.Name, res.ListsAdded, res.ListsUpdated, res.CardsAdded, res.CardsUpdated)
+	if report != nil {
+		for _, line := range report.Lines() {
+			fmt.Fprintf(c.App.Writer, "  %s\n", line)
+		}
+	}
+	return nil
+}
+
+func importCSV(c *cli.Context, r io.Reader, name string) error {
+	rows, err := archive.ReadCSV(r)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	ctx := context.Background()
+	be, err := store.Open()
+	if err != nil {
+		return err
+	}
+	board, err := cmdutil.Board(ctx, be, boardVar)
+	if err != nil {
+		return err
+	}
+	plan, err := archive.PlanCSV(ctx, board, rows)
+	if err != nil {
+		return err
+	}
+	added, updated := "would add", "would update"
+	if !dryRunVar {
+		if err := plan.Apply(ctx, be); err != nil {
+			return err
+		}
+		added, updated = "added", "updated"
+	}
+	fmt.Fprintf(c.App.Writer, "Board %q: %s %d list(s) and %d card(s), %s %d card(s), %d unchanged\n",
+		board.Name, added, len(plan.Lists), len(plan.Added), updated, len(plan.Updated), plan.Unchanged)
+	for _, line := range plan.Lines() {
+		fmt.Fprintf(c.App.Writer, "  %s\n", line)
+	}
+	return nil
+}
+
+func ExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "export",
+		Usage:  "write a board with its lists, cards and labels to a JSON file, or its cards to a CSV file",
+		Flags:  exportFlags,
+		Action: cmdutil.Action(Export),
+	}
+}
+
+func ImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "add a board from a file written by export or a Trello board export",
+		Description: "Without options the board keeps the ids of the file and the import fails if\n" +
+			"they are taken. With --from csv the cards of the file update the cards of\n" +
+			"--board with the same id, and the others are added. Exits with 2 on invalid\n" +
+			"files, 3 when something is not found and 4 on conflicts.",
+		ArgsUsage: "FILE",
+		Flags:     importFlags,
+		Action:    cmdutil.Action(Import),
+	}
+}
@@ -0,0 +1,303 @@
+// Package archive converts whole boards to and from documents that can be
+// kept as backups or moved between databases.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// Version is the version of the documents written by Write. Read accepts
+// documents up to this version.
+const Version = 1
+
+var ErrVersion = errors.New("unsupported document version")
+
+// Document is a board with everything on it.
+type Document struct {
+	Version int
+	Board   Board
+}
+
+type Board struct {
+	Id, Name string
+	Labels   []backend.Label
+	Lists    []List
+}
+
+type List struct {
+	Id, Name string
+	Pos      float64
+	Order    backend.Order
+	Cards    []Card
+}
+
+type Card struct {
+	Id, Name, Description string
+	Value, Effort, Work   int
+	Labels                []backend.Label
+	Pos                   float64
+	LastUpdate, MovedAt   time.Time
+}
+
+// Export returns the document of board, with its lists and their cards in
+// the order they are shown.
+func Export(ctx context.Context, board *backend.Board) (*Document, error) {
+	doc := &Document{
+		Version: Version,
+		Board:   Board{Id: board.Id, Name: board.Name, Labels: append([]backend.Label{}, board.Labels...)},
+	}
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		l.SetBackend(board.GetBackend())
+		cards, err := l.Cards(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list := List{Id: l.Id, Name: l.Name, Pos: l.Pos, Order: l.Order, Cards: []Card{}}
+		for _, c := range cards {
+			list.Cards = append(list.Cards, Card{
+				Id: c.Id, Name: c.Name, Description: c.Description,
+				Value: c.Value, Effort: c.Effort, Work: c.Work,
+				Labels: append([]backend.Label{}, c.Labels...), Pos: c.Pos,
+				LastUpdate: c.LastUpdate, MovedAt: c.MovedAt,
+			})
+		}
+		doc.Board.Lists = append(doc.Board.Lists, list)
+	}
+	return doc, nil
+}
+
+// Write writes doc to w as indented JSON.
+func Write(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Read reads a document written by Write.
+func Read(r io.Reader) (*Document, error) {
+	doc := new(Document)
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	if doc.Version < 1 || doc.Version > Version {
+		return nil, fmt.Errorf("version %d: %w", doc.Version, ErrVersion)
+	}
+	if doc.Board.Name == "" {
+		return nil, fmt.Errorf("document has no board name: %w", backend.ErrInvalid)
+	}
+	for _, l := range doc.Board.Lists {
+		for _, c := range l.Cards {
+			if c.Value < 0 || c.Effort < 0 || c.Work < 0 {
+				return nil, fmt.Errorf("card %s has a negative value, effort or work: %w", c.Id, backend.ErrInvalid)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// Mode is how Import treats a board that is already in the backend.
+type Mode int
+
+const (
+	// Create adds the board with the ids of the document and fails with
+	// backend.ErrConflict if any of them is taken.
+	Create Mode = iota
+	// Merge adds the board like Create if it is missing, and otherwise
+	// updates it and the lists and cards with the ids of the document,
+	// adding those that are missing and keeping those the document does
+	// not mention.
+	Merge
+	// Copy adds the board with new ids, so it can be imported next to the
+	// board it was exported from.
+	Copy
+)
+
+// Result counts the records written by Import.
+type Result struct {
+	Board                    *backend.Board
+	ListsAdded, ListsUpdated int
+	CardsAdded, CardsUpdated int
+}
+
+// Import writes doc to be as a board called name, or the name in the
+// document if name is empty, within a single transaction where the backend
+// supports them.
+func Import(ctx context.Context, be backend.Backend, doc *Document, mode Mode, name string) (*Result, error) {
+	if mode == Copy {
+		doc = renumber(doc)
+	}
+	res := new(Result)
+	err := backend.RunTx(ctx, be, func(be backend.Backend) error {
+		res.ListsAdded, res.ListsUpdated, res.CardsAdded, res.CardsUpdated = 0, 0, 0, 0
+		board, err := importBoard(ctx, be, doc, mode, name)
+		if err != nil {
+			return err
+		}
+		res.Board = board
+		for _, l := range doc.Board.Lists {
+			list := &backend.List{Id: l.Id, BoardId: board.Id, Name: l.Name, Pos: l.Pos, Order: l.Order}
+			added, err := put(ctx, list.Id, mode, be.GetList,
+				func(current *backend.List) error {
+					if current.BoardId != board.Id {
+						return fmt.Errorf("list %s belongs to another board: %w", list.Id, backend.ErrConflict)
+					}
+					list.Rev = current.Rev
+					return be.UpdateList(ctx, list)
+				},
+				func() error { return be.AddList(ctx, list) })
+			if err != nil {
+				return err
+			}
+			count(added, &res.ListsAdded, &res.ListsUpdated)
+			for _, c := range l.Cards {
+				card := &backend.Card{
+					Id: c.Id, ListId: list.Id, Name: c.Name, Description: c.Description,
+					Value: c.Value, Effort: c.Effort, Work: c.Work,
+					Labels: append([]backend.Label{}, c.Labels...), Pos: c.Pos, MovedAt: c.MovedAt,
+				}
+				added, err := put(ctx, card.Id, mode, be.GetCard,
+					func(current *backend.Card) error {
+						if err := sameBoard(ctx, be, current, board.Id); err != nil {
+							return err
+						}
+						card.Rev = current.Rev
+						return be.UpdateCard(ctx, card)
+					},
+					func() error { return be.AddCard(ctx, card) })
+				if err != nil {
+					return err
+				}
+				count(added, &res.CardsAdded, &res.CardsUpdated)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Board.SetBackend(be)
+	return res, nil
+}
+
+func importBoard(ctx context.Context, be backend.Backend, doc *Document, mode Mode, name string) (*backend.Board, error) {
+	board := &backend.Board{Id: doc.Board.Id, Name: doc.Board.Name, Labels: doc.Board.Labels}
+	if name != "" {
+		board.Name = name
+	}
+	current, err := be.GetBoard(ctx, board.Id)
+	switch {
+	case errors.Is(err, backend.ErrNotFound):
+		if err := nameFree(ctx, be, board.Name); err != nil {
+			return nil, err
+		}
+		return board, be.AddBoard(ctx, board)
+	case err != nil:
+		return nil, err
+	case mode != Merge:
+		return nil, fmt.Errorf("board %s already exists as %q: %w", board.Id, current.Name, backend.ErrConflict)
+	}
+	// Merging keeps the name of the board unless another one is asked for,
+	// and adds the labels of the document to its palette.
+	if name != "" && name != current.Name {
+		if err := nameFree(ctx, be, name); err != nil {
+			return nil, err
+		}
+		current.Name = name
+	}
+	for _, l := range doc.Board.Labels {
+		if i := labelIndex(current.Labels, l.Name); i >= 0 {
+			current.Labels[i] = l
+		} else {
+			current.Labels = append(current.Labels, l)
+		}
+	}
+	return current, be.UpdateBoard(ctx, current)
+}
+
+// nameFree fails with backend.ErrConflict if a board is called name, as
+// boards are opened by name.
+func nameFree(ctx context.Context, be backend.Backend, name string) error {
+	boards, err := be.ListBoards(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range boards {
+		if b.Name == name {
+			return fmt.Errorf("board %q already exists: %w", name, backend.ErrConflict)
+		}
+	}
+	return nil
+}
+
+func labelIndex(labels []backend.Label, name string) int {
+	for i, l := range labels {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameBoard(ctx context.Context, be backend.Backend, card *backend.Card, boardId string) error {
+	list, err := be.GetList(ctx, card.ListId)
+	if err != nil {
+		return err
+	}
+	if list.BoardId != boardId {
+		return fmt.Errorf("card %s belongs to another board: %w", card.Id, backend.ErrConflict)
+	}
+	return nil
+}
+
+// put adds the record with id, or updates it when merging and it exists.
+// It reports whether the record was added.
+func put[T any](ctx context.Context, id string, mode Mode,
+	get func(context.Context, string) (*T, error), update func(*T) error, add func() error) (bool, error) {
+	current, err := get(ctx, id)
+	switch {
+	case errors.Is(err, backend.ErrNotFound):
+		return true, add()
+	case err != nil:
+		return false, err
+	case mode != Merge:
+		return false, fmt.Errorf("%s already exists: %w", id, backend.ErrConflict)
+	}
+	return false, update(current)
+}
+
+func count(added bool, adds, updates *int) {
+	if added {
+		*adds++
+	} else {
+		*updates++
+	}
+}
+
+// renumber returns a copy of doc with new ids.
+func renumber(doc *Document) *Document {
+	out := *doc
+	out.Board.Id = uuid.New().String()
+	out.Board.Lists = make([]List, len(doc.Board.Lists))
+	for i, l := range doc.Board.Lists {
+		l.Id = uuid.New().String()
+		l.Cards = append([]Card(nil), l.Cards...)
+		for j := range l.Cards {
+			l.Cards[j].Id = uuid.New().String()
+		}
+		out.Board.Lists[i] = l
+	}
+	return &out
+}
@@ -0,0 +1,144 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+)
+
+func seed(t *testing.T) *backend.Board {
+	t.Helper()
+	ctx := context.Background()
+	be := memory.New()
+	board := &backend.Board{Name: "Main", Labels: []backend.Label{{Name: "bug", Color: "red"}}}
+	if err := be.AddBoard(ctx, board); err != nil {
+		t.Fatal(err)
+	}
+	board.SetBackend(be)
+	todo := &backend.List{Name: "TODO"}
+	done := &backend.List{Name: "DONE", Pos: 1, Order: backend.ManualOrder}
+	if err := board.AddLists(ctx, todo, done); err != nil {
+		t.Fatal(err)
+	}
+	todo.SetBackend(be)
+	done.SetBackend(be)
+	if err := todo.AddCards(ctx, &backend.Card{Name: "crash", Value: 3, Labels: board.Labels}); err != nil {
+		t.Fatal(err)
+	}
+	if err := done.AddCards(ctx, &backend.Card{Name: "a"}, &backend.Card{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+func roundTrip(t *testing.T, board *backend.Board) *Document {
+	t.Helper()
+	doc, err := Export(context.Background(), board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return read
+}
+
+// summary describes the lists and cards of board in order.
+func summary(t *testing.T, board *backend.Board) string {
+	t.Helper()
+	doc, err := Export(context.Background(), board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	for _, l := range doc.Board.Lists {
+		names := []string{}
+		for _, c := range l.Cards {
+			names = append(names, c.Name)
+		}
+		parts = append(parts, l.Name+":"+strings.Join(names, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	board := seed(t)
+	doc := roundTrip(t, board)
+
+	be := memory.New()
+	res, err := Import(ctx, be, doc, Create, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Board.Id != board.Id || res.ListsAdded != 2 || res.CardsAdded != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got, want := summary(t, res.Board), summary(t, board); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := res.Board.Labels; len(got) != 1 || got[0].Color != "red" {
+		t.Fatalf("palette not imported: %v", got)
+	}
+
+	if _, err := Import(ctx, be, doc, Create, ""); !errors.Is(err, backend.ErrConflict) {
+		t.Fatalf("want ErrConflict, got %v", err)
+	}
+
+	doc.Board.Lists[0].Cards[0].Name = "crash on login"
+	doc.Board.Labels = append(doc.Board.Labels, backend.Label{Name: "feature"})
+	res, err = Import(ctx, be, doc, Merge, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ListsUpdated != 2 || res.CardsUpdated != 3 || res.CardsAdded != 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got := summary(t, res.Board); got != "TODO:crash on login DONE:a,b" {
+		t.Fatalf("merge gave %q", got)
+	}
+	if len(res.Board.Labels) != 2 {
+		t.Fatalf("palette not merged: %v", res.Board.Labels)
+	}
+
+	if _, err := Import(ctx, be, doc, Copy, ""); !errors.Is(err, backend.ErrConflict) {
+		t.Fatalf("copy with a taken name: want ErrConflict, got %v", err)
+	}
+	res, err = Import(ctx, be, doc, Copy, "Main copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Board.Id == board.Id || res.CardsAdded != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	boards, _ := be.ListBoards(ctx)
+	if len(boards) != 2 {
+		t.Fatalf("want 2 boards, got %d", len(boards))
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, s := range []string{`{"Version": 2, "Board": {"Name": "x"}}`, `{"Board": {"Name": "x"}}`} {
+		if _, err := Read(strings.NewReader(s)); !errors.Is(err, ErrVersion) {
+			t.Errorf("%s: want ErrVersion, got %v", s, err)
+		}
+	}
+	for _, s := range []string{
+		`{"Version": 1}`,
+		`{"Version": 1, "Board": {"Name": "x", "Lists": [{"Cards": [{"Work": -5}]}]}}`,
+	} {
+		if _, err := Read(strings.NewReader(s)); !errors.Is(err, backend.ErrInvalid) {
+			t.Errorf("%s: want ErrInvalid, got %v", s, err)
+		}
+	}
+}
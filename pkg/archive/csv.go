@@ -0,0 +1,385 @@
+package archive
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// Columns are the columns of the files written by WriteCSV.
+var Columns = []string{"id", "list", "name", "description", "value", "effort", "work", "labels", "pos"}
+
+// WriteCSV writes the cards of doc to w, one row per card after a header
+// row of Columns. Labels are written as names separated by commas.
+func WriteCSV(w io.Writer, doc *Document) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, l := range doc.Board.Lists {
+		for _, c := range l.Cards {
+			record := []string{
+				c.Id, l.Name, c.Name, c.Description,
+				strconv.Itoa(c.Value), strconv.Itoa(c.Effort), strconv.Itoa(c.Work),
+				labelNames(c.Labels), strconv.FormatFloat(c.Pos, 'g', -1, 64),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Row is a card read by ReadCSV.
+type Row struct {
+	// Line is the line of the row in the file.
+	Line                        int
+	Id, List, Name, Description string
+	Value, Effort, Work         int
+	Labels                      []string
+	Pos                         float64
+	// Set holds the columns of the file. The others keep their current
+	// value when a card is updated.
+	Set map[string]bool
+}
+
+// ReadCSV reads the rows of a file with a header row naming some of
+// Columns in any order, so that a spreadsheet can leave out the columns it
+// does not change.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("no header row: %w", backend.ErrInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, backend.ErrInvalid)
+	}
+	set := make(map[string]bool)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if !slices.Contains(Columns, h) {
+			return nil, fmt.Errorf("unknown column %q, want some of %s: %w", header[i], strings.Join(Columns, ","), backend.ErrInvalid)
+		}
+		if set[h] {
+			return nil, fmt.Errorf("column %q appears twice: %w", h, backend.ErrInvalid)
+		}
+		set[h] = true
+		header[i] = h
+	}
+	var rows []Row
+	ids := make(map[string]int)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, backend.ErrInvalid)
+		}
+		line, _ := cr.FieldPos(0)
+		row := Row{Line: line, Labels: []string{}, Set: set}
+		for i, h := range header {
+			if err := row.set(h, record[i]); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v: %w", line, h, err, backend.ErrInvalid)
+			}
+		}
+		if row.Id != "" {
+			if prev, ok := ids[row.Id]; ok {
+				return nil, fmt.Errorf("line %d: card %s is already on line %d: %w", line, row.Id, prev, backend.ErrInvalid)
+			}
+			ids[row.Id] = line
+		}
+		rows = append(rows, row)
+	}
+}
+
+// set reads a cell of column. Descriptions are kept exactly as read, as
+// Markdown gives meaning to leading and trailing whitespace.
+func (r *Row) set(column, value string) error {
+	if column == "description" {
+		r.Description = value
+		return nil
+	}
+	value = strings.TrimSpace(value)
+	var err error
+	switch column {
+	case "id":
+		r.Id = value
+	case "list":
+		r.List = value
+	case "name":
+		r.Name = value
+	case "value":
+		r.Value, err = number(value)
+	case "effort":
+		r.Effort, err = number(value)
+	case "work":
+		r.Work, err = number(value)
+	case "labels":
+		for _, l := range strings.Split(value, ",") {
+			if l = strings.TrimSpace(l); l != "" && !slices.Contains(r.Labels, l) {
+				r.Labels = append(r.Labels, l)
+			}
+		}
+	case "pos":
+		if value != "" {
+			r.Pos, err = strconv.ParseFloat(value, 64)
+		}
+	}
+	return err
+}
+
+// number parses a non-negative integer, reading an empty cell as zero.
+func number(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		err = errors.New("must not be negative")
+	}
+	return n, err
+}
+
+// Change is a card that a Plan adds or updates.
+type Change struct {
+	// Before is the stored card, nil when the card is added.
+	Before, After *backend.Card
+	// From and To are the names of the lists of Before and After.
+	From, To string
+}
+
+// Plan is what importing rows into a board would write, worked out
+// without writing anything.
+type Plan struct {
+	Board *backend.Board
+	// Lists are the lists to add, for rows naming lists the board lacks.
+	Lists          []*backend.List
+	Added, Updated []Change
+	Unchanged      int
+}
+
+// PlanCSV matches rows to the cards of board by id. Rows without an id, or
+// with an id no card has, add cards; the others update the card with their
+// id. Lists are matched by name ignoring case and added when missing, and
+// labels must be in the palette of the board.
+func PlanCSV(ctx context.Context, board *backend.Board, rows []Row) (*Plan, error) {
+	be := board.GetBackend()
+	plan := &Plan{Board: board}
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// last is the position after the last card of each list, for cards
+	// that come without one.
+	cards, last := make(map[string]*backend.Card), make(map[string]float64)
+	for _, l := range lists {
+		l.SetBackend(be)
+		listCards, err := l.Cards(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range listCards {
+			cards[c.Id] = c
+			if c.Pos+1 > last[l.Id] {
+				last[l.Id] = c.Pos + 1
+			}
+		}
+	}
+	all := lists
+	list := func(name string) *backend.List {
+		for _, l := range all {
+			if strings.EqualFold(l.Name, name) {
+				return l
+			}
+		}
+		added := &backend.List{Id: uuid.New().String(), BoardId: board.Id, Name: name}
+		if n := len(all); n > 0 {
+			added.Pos = all[n-1].Pos + 1
+		}
+		all = append(all, added)
+		plan.Lists = append(plan.Lists, added)
+		return added
+	}
+	listName := func(id string) string {
+		for _, l := range all {
+			if l.Id == id {
+				return l.Name
+			}
+		}
+		return ""
+	}
+
+	for _, row := range rows {
+		current, ok := cards[row.Id]
+		if !ok && row.Id != "" {
+			switch _, err := be.GetCard(ctx, row.Id); {
+			case err == nil:
+				return nil, fmt.Errorf("line %d: card %s is on another board: %w", row.Line, row.Id, backend.ErrConflict)
+			case !errors.Is(err, backend.ErrNotFound):
+				return nil, err
+			}
+		}
+		var card backend.Card
+		if ok {
+			card = *current
+			card.Labels = append([]backend.Label(nil), current.Labels...)
+		} else {
+			if row.Name == "" || row.List == "" {
+				return nil, fmt.Errorf("line %d: a new card needs a name and a list: %w", row.Line, backend.ErrInvalid)
+			}
+			card.Id = row.Id
+			if card.Id == "" {
+				card.Id = uuid.New().String()
+			}
+		}
+		if err := row.apply(&card, board, list); err != nil {
+			return nil, err
+		}
+		if !row.Set["pos"] && (!ok || card.ListId != current.ListId) {
+			card.Pos = last[card.ListId]
+		}
+		if card.Pos+1 > last[card.ListId] {
+			last[card.ListId] = card.Pos + 1
+		}
+		change := Change{After: &card, To: listName(card.ListId)}
+		if !ok {
+			plan.Added = append(plan.Added, change)
+			continue
+		}
+		change.Before, change.From = current, listName(current.ListId)
+		if len(change.Fields()) == 0 {
+			plan.Unchanged++
+			continue
+		}
+		if card.ListId != current.ListId {
+			card.MovedAt = time.Now()
+		}
+		plan.Updated = append(plan.Updated, change)
+	}
+	return plan, nil
+}
+
+func (r *Row) apply(card *backend.Card, board *backend.Board, list func(string) *backend.List) error {
+	if r.Set["list"] && r.List != "" {
+		card.ListId = list(r.List).Id
+	}
+	if r.Set["name"] && r.Name != "" {
+		card.Name = r.Name
+	}
+	if r.Set["description"] {
+		card.Description = r.Description
+	}
+	if r.Set["value"] {
+		card.Value = r.Value
+	}
+	if r.Set["effort"] {
+		card.Effort = r.Effort
+	}
+	if r.Set["work"] {
+		card.Work = r.Work
+	}
+	if r.Set["pos"] {
+		card.Pos = r.Pos
+	}
+	if r.Set["labels"] {
+		card.Labels = []backend.Label{}
+		for _, name := range r.Labels {
+			label, ok := board.Label(name)
+			if !ok {
+				return fmt.Errorf("line %d: label %q is not in the palette of board %q: %w", r.Line, name, board.Name, backend.ErrInvalid)
+			}
+			card.Labels = append(card.Labels, label)
+		}
+	}
+	return nil
+}
+
+// Fields describes what the change does to each column it changes, such
+// as "value 3 -> 5".
+func (c Change) Fields() []string {
+	if c.Before == nil {
+		return nil
+	}
+	var fields []string
+	diff := func(column, before, after string) {
+		if before != after {
+			fields = append(fields, fmt.Sprintf("%s %s -> %s", column, before, after))
+		}
+	}
+	b, a := c.Before, c.After
+	diff("list", c.From, c.To)
+	diff("name", strconv.Quote(b.Name), strconv.Quote(a.Name))
+	if b.Description != a.Description {
+		fields = append(fields, "description changed")
+	}
+	diff("value", strconv.Itoa(b.Value), strconv.Itoa(a.Value))
+	diff("effort", strconv.Itoa(b.Effort), strconv.Itoa(a.Effort))
+	diff("work", strconv.Itoa(b.Work), strconv.Itoa(a.Work))
+	diff("labels", "["+labelNames(b.Labels)+"]", "["+labelNames(a.Labels)+"]")
+	diff("pos", strconv.FormatFloat(b.Pos, 'g', -1, 64), strconv.FormatFloat(a.Pos, 'g', -1, 64))
+	return fields
+}
+
+// Lines describes the plan for people, one list or card per line.
+func (p *Plan) Lines() []string {
+	var lines []string
+	for _, l := range p.Lists {
+		lines = append(lines, fmt.Sprintf("+ list %q", l.Name))
+	}
+	for _, c := range p.Added {
+		lines = append(lines, fmt.Sprintf("+ card %q in %q", c.After.Name, c.To))
+	}
+	for _, c := range p.Updated {
+		lines = append(lines, fmt.Sprintf("~ card %s %q: %s", backend.ShortId(c.After.Id), c.Before.Name, strings.Join(c.Fields(), ", ")))
+	}
+	return lines
+}
+
+// Apply writes the plan to be within a single transaction where the
+// backend supports them. Cards changed since the plan was made fail the
+// import with backend.ErrConflict.
+func (p *Plan) Apply(ctx context.Context, be backend.Backend) error {
+	return backend.RunTx(ctx, be, func(be backend.Backend) error {
+		for _, l := range p.Lists {
+			list := *l
+			if err := be.AddList(ctx, &list); err != nil {
+				return err
+			}
+		}
+		for _, c := range p.Added {
+			card := *c.After
+			if err := be.AddCard(ctx, &card); err != nil {
+				return err
+			}
+		}
+		for _, c := range p.Updated {
+			card := *c.After
+			if err := be.UpdateCard(ctx, &card); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func labelNames(labels []backend.Label) string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func TestCSV(t *testing.T) {
+	ctx := context.Background()
+	board := seed(t)
+	doc, err := Export(ctx, board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[1][2] != "crash" || records[1][7] != "bug" {
+		t.Fatalf("unexpected CSV %q", records)
+	}
+
+	// Rows that leave out columns keep the values of those columns.
+	crash, a := records[1][0], records[2][0]
+	in := "ID,list,value,labels\n" +
+		crash + ",TODO,5,\n" +
+		a + ",DONE,0,\n" +
+		",backlog,1,bug\n"
+	rows, err := ReadCSV(strings.NewReader(in))
+	if err == nil {
+		_, err = PlanCSV(ctx, board, rows)
+	}
+	if !errors.Is(err, backend.ErrInvalid) {
+		t.Fatalf("new card without a name: want ErrInvalid, got %v", err)
+	}
+
+	in = "id,list,name,value,labels\n" +
+		crash + ",Backlog,,5,\n" +
+		a + ",DONE,,0,\n" +
+		",backlog,triage,1,bug\n"
+	rows, err = ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanCSV(ctx, board, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Lists) != 1 || len(plan.Added) != 1 || len(plan.Updated) != 1 || plan.Unchanged != 1 {
+		t.Fatalf("unexpected plan %v", plan.Lines())
+	}
+	want := "list TODO -> Backlog, value 3 -> 5, labels [bug] -> []"
+	if got := strings.Join(plan.Updated[0].Fields(), ", "); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := summary(t, board); got != "TODO:crash DONE:a,b" {
+		t.Fatalf("planning wrote to the board: %q", got)
+	}
+
+	if err := plan.Apply(ctx, board.GetBackend()); err != nil {
+		t.Fatal(err)
+	}
+	if got := summary(t, board); got != "TODO: DONE:a,b Backlog:crash,triage" {
+		t.Fatalf("unexpected board %q", got)
+	}
+	if err := plan.Apply(ctx, board.GetBackend()); err == nil {
+		t.Fatal("applying a stale plan succeeded")
+	}
+}
+
+func TestCSV_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	board := seed(t)
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards, err := lists[0].Cards(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards[0].Description = "    indented code\n\nline with a break  \n"
+	if err := board.GetBackend().UpdateCard(ctx, cards[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Export(ctx, board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanCSV(ctx, board, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Unchanged != len(rows) || len(plan.Lines()) != 0 {
+		t.Fatalf("want %d unchanged cards, got %d: %v", len(rows), plan.Unchanged, plan.Lines())
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"id,owner\n",
+		"id,name,id\n",
+		"name,value\nx,many\n",
+		"name,effort\nx,-1\n",
+		"id,name\n1,x\n1,y\n",
+	} {
+		if _, err := ReadCSV(strings.NewReader(s)); !errors.Is(err, backend.ErrInvalid) {
+			t.Errorf("%q: want ErrInvalid, got %v", s, err)
+		}
+	}
+}
@@ -2,13 +2,31 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"time"
 )
 
+var (
+	// ErrNotFound is returned when a board, list or card does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write clashes with an existing record.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalid is returned when a record fails validation, such as
+	// referring to a parent that does not exist.
+	ErrInvalid = errors.New("invalid")
+)
+
 type Board struct {
 	backend  Backend `json:"-"`
 	Id, Name string
+	// Labels is the palette offered when labelling the cards of the board.
+	Labels []Label
+	// Rev is the revision of the stored record, stamped by the backend on
+	// every write. Updates carrying a stale Rev fail with ErrConflict. Lists
+	// and cards carry the same field, and cards also get LastUpdate stamped.
+	Rev uint64
 }
 
 func (b *Board) SetBackend(be Backend) {
@@ -23,35 +41,146 @@ func (b *Board) Delete(ctx context.Context) error {
 	return b.backend.DeleteBoard(ctx, b.Id)
 }
 
+// Clone copies the board with all of its lists and cards into a new board
+// called name. The copies get new ids and start again at revision 1.
+func (b *Board) Clone(ctx context.Context, name string) (*Board, error) {
+	clone := &Board{Name: name, Labels: append([]Label(nil), b.Labels...)}
+	err := RunTx(ctx, b.backend, func(be Backend) error {
+		if err := be.AddBoard(ctx, clone); err != nil {
+			return err
+		}
+		lists, err := be.ListLists(ctx, b.Id)
+		if err != nil {
+			return err
+		}
+		for _, l := range lists {
+			cards, err := be.ListCards(ctx, l.Id)
+			if err != nil {
+				return err
+			}
+			list := &List{BoardId: clone.Id, Name: l.Name, Pos: l.Pos, Order: l.Order}
+			if err := be.AddList(ctx, list); err != nil {
+				return err
+			}
+			for _, c := range cards {
+				card := *c
+				card.backend, card.Id, card.ListId, card.Rev = nil, "", list.Id, 0
+				card.Labels = append([]Label(nil), c.Labels...)
+				if err := be.AddCard(ctx, &card); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	clone.SetBackend(b.backend)
+	return clone, nil
+}
+
 func (b *Board) Lists(ctx context.Context) ([]*List, error) {
 	lists, err := b.backend.ListLists(ctx, b.Id)
 	if err != nil {
 		return nil, err
 	}
+	sortLists(lists)
+	return lists, nil
+}
+
+func sortLists(lists []*List) {
 	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Pos == lists[j].Pos {
+			return lists[i].Id < lists[j].Id
+		}
 		return lists[i].Pos < lists[j].Pos
 	})
-	return lists, nil
 }
 
 func (b *Board) Update(ctx context.Context) error {
 	return b.backend.UpdateBoard(ctx, b)
 }
 
-func (b *Board) AddLists(ctx context.Context, lists ...*List) error {
-	for _, list := range lists {
-		list.BoardId = b.Id
-		if err := b.backend.AddList(ctx, list); err != nil {
+// Reorder moves list to index among the other lists of the board, in the
+// same way as List.Reorder does for cards.
+func (b *Board) Reorder(ctx context.Context, list *List, index int) error {
+	return RunTx(ctx, b.backend, func(be Backend) error {
+		lists, err := be.ListLists(ctx, b.Id)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
+		sortLists(lists)
+		others := make([]*List, 0, len(lists))
+		for _, l := range lists {
+			if l.Id != list.Id {
+				others = append(others, l)
+			}
+		}
+		if index < 0 {
+			index = 0
+		}
+		if index > len(others) {
+			index = len(others)
+		}
+		positions := make([]float64, len(others))
+		for i, l := range others {
+			positions[i] = l.Pos
+		}
+		pos, ok := posBetween(positions, index)
+		if !ok {
+			for i, l := range others {
+				l.Pos = float64(i)
+				positions[i] = l.Pos
+				if err := be.UpdateList(ctx, l); err != nil {
+					return err
+				}
+			}
+			pos, _ = posBetween(positions, index)
+		}
+		prev := list.Pos
+		list.Pos = pos
+		if err := be.UpdateList(ctx, list); err != nil {
+			list.Pos = prev
+			return err
+		}
+		return nil
+	})
+}
+
+func (b *Board) AddLists(ctx context.Context, lists ...*List) error {
+	return RunTx(ctx, b.backend, func(be Backend) error {
+		for _, list := range lists {
+			list.BoardId = b.Id
+			if err := be.AddList(ctx, list); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
+// Order is how the cards of a list are sorted.
+type Order string
+
+const (
+	// PriorityOrder sorts cards by HasHigherPriority. It is the zero value
+	// so lists stored before ordering modes existed keep their behavior.
+	PriorityOrder Order = ""
+	// ManualOrder sorts cards by Pos, as arranged with List.Reorder.
+	ManualOrder Order = "manual"
+)
+
+// minPosGap is the closest two adjacent manually ordered cards, or two
+// adjacent lists, may get before all their positions are spread out again.
+const minPosGap = 1e-6
+
 type List struct {
 	backend           Backend `json:"-"`
 	BoardId, Id, Name string
 	Pos               float64
+	Rev               uint64
+	Order             Order
 }
 
 func (l *List) SetBackend(be Backend) {
@@ -67,20 +196,132 @@ func (l *List) Cards(ctx context.Context) ([]*Card, error) {
 	if err != nil {
 		return nil, err
 	}
+	l.sortCards(cards)
+	return cards, nil
+}
+
+func (l *List) sortCards(cards []*Card) {
+	if l.Order == ManualOrder {
+		sort.Slice(cards, func(i, j int) bool {
+			if cards[i].Pos == cards[j].Pos {
+				return cards[i].Id < cards[j].Id
+			}
+			return cards[i].Pos < cards[j].Pos
+		})
+		return
+	}
 	sort.Slice(cards, func(i, j int) bool {
 		return cards[i].HasHigherPriority(cards[j])
 	})
-	return cards, nil
+}
+
+// SetOrder switches how the cards of the list are sorted. Switching to
+// ManualOrder starts from the current priority order.
+func (l *List) SetOrder(ctx context.Context, order Order) error {
+	if l.Order == order {
+		return nil
+	}
+	return RunTx(ctx, l.backend, func(be Backend) error {
+		if order == ManualOrder {
+			if err := l.sortByPriority(ctx, be); err != nil {
+				return err
+			}
+		}
+		prev := l.Order
+		l.Order = order
+		if err := be.UpdateList(ctx, l); err != nil {
+			l.Order = prev
+			return err
+		}
+		return nil
+	})
+}
+
+// Reorder moves card to index among the other cards of the manually
+// ordered list by placing its Pos between its new neighbours. When they are
+// too close to fit it in, the positions of the whole list are spread out
+// first.
+func (l *List) Reorder(ctx context.Context, card *Card, index int) error {
+	return RunTx(ctx, l.backend, func(be Backend) error {
+		cards, err := be.ListCards(ctx, l.Id)
+		if err != nil {
+			return err
+		}
+		l.sortCards(cards)
+		others := make([]*Card, 0, len(cards))
+		for _, c := range cards {
+			if c.Id != card.Id {
+				others = append(others, c)
+			}
+		}
+		if index < 0 {
+			index = 0
+		}
+		if index > len(others) {
+			index = len(others)
+		}
+		pos, ok := posBetween(cardPositions(others), index)
+		if !ok {
+			for i, c := range others {
+				c.Pos = float64(i)
+				if err := be.UpdateCard(ctx, c); err != nil {
+					return err
+				}
+			}
+			pos, _ = posBetween(cardPositions(others), index)
+		}
+		prev := card.Pos
+		card.Pos = pos
+		if err := be.UpdateCard(ctx, card); err != nil {
+			card.Pos = prev
+			return err
+		}
+		return nil
+	})
+}
+
+// posBetween returns the position for an item inserted at index among
+// items at the sorted positions, and false if its neighbours are too close
+// together.
+func posBetween(positions []float64, index int) (float64, bool) {
+	switch {
+	case len(positions) == 0:
+		return 0, true
+	case index == 0:
+		return positions[0] - 1, true
+	case index == len(positions):
+		return positions[len(positions)-1] + 1, true
+	}
+	lo, hi := positions[index-1], positions[index]
+	return lo + (hi-lo)/2, hi-lo >= minPosGap
+}
+
+func cardPositions(cards []*Card) []float64 {
+	positions := make([]float64, len(cards))
+	for i, c := range cards {
+		positions[i] = c.Pos
+	}
+	return positions
 }
 
 func (l *List) Sort(ctx context.Context) error {
-	cards, err := l.Cards(ctx)
+	return RunTx(ctx, l.backend, func(be Backend) error {
+		return l.sortByPriority(ctx, be)
+	})
+}
+
+// sortByPriority stores the priority order of the cards in their Pos.
+func (l *List) sortByPriority(ctx context.Context, be Backend) error {
+	cards, err := be.ListCards(ctx, l.Id)
 	if err != nil {
 		return err
 	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].HasHigherPriority(cards[j])
+	})
 	for i, c := range cards {
 		c.Pos = float64(i)
-		if err := c.Update(ctx); err != nil {
+		if err := be.UpdateCard(ctx, c); err != nil {
 			return err
 		}
 	}
@@ -95,23 +336,62 @@ func (l *List) Delete(ctx context.Context) error {
 	return l.backend.DeleteList(ctx, l.Id)
 }
 
-func (l *List) AddCards(ctx context.Context, cards ...*Card) error {
-	for _, card := range cards {
-		card.ListId = l.Id
-		if err := l.backend.AddCard(ctx, card); err != nil {
+// DeleteMovingCards moves the cards of the list to target, keeping their
+// order, before deleting the list.
+func (l *List) DeleteMovingCards(ctx context.Context, target *List) error {
+	if target.Id == l.Id {
+		return fmt.Errorf("list %q cannot take its own cards: %w", l.Id, ErrInvalid)
+	}
+	return RunTx(ctx, l.backend, func(be Backend) error {
+		cards, err := be.ListCards(ctx, l.Id)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
+		l.sortCards(cards)
+		for _, c := range cards {
+			c.SetBackend(be)
+			if err := c.Move(ctx, target); err != nil {
+				return err
+			}
+		}
+		return be.DeleteList(ctx, l.Id)
+	})
+}
+
+// AddCards adds cards to the list, after the existing cards if the list is
+// manually ordered.
+func (l *List) AddCards(ctx context.Context, cards ...*Card) error {
+	return RunTx(ctx, l.backend, func(be Backend) error {
+		next := 0.0
+		if l.Order == ManualOrder {
+			existing, err := be.ListCards(ctx, l.Id)
+			if err != nil {
+				return err
+			}
+			l.sortCards(existing)
+			next, _ = posBetween(cardPositions(existing), len(existing))
+		}
+		for i, card := range cards {
+			card.ListId = l.Id
+			if l.Order == ManualOrder {
+				card.Pos = next + float64(i)
+			}
+			if err := be.AddCard(ctx, card); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 type Card struct {
 	backend                       Backend `json:"-"`
-	LastUpdate                    time.Time
+	LastUpdate, MovedAt           time.Time
 	ListId, Id, Name, Description string
 	Value, Effort, Work           int
 	Labels                        []Label
 	Pos                           float64
+	Rev                           uint64
 }
 
 func (c *Card) SetBackend(be Backend) {
@@ -126,6 +406,51 @@ func (c *Card) Update(ctx context.Context) error {
 	return c.backend.UpdateCard(ctx, c)
 }
 
+// LogWork adds n to the work spent on the card, never going below zero.
+func (c *Card) LogWork(ctx context.Context, n int) error {
+	work := c.Work
+	c.Work += n
+	if c.Work < 0 {
+		c.Work = 0
+	}
+	if err := c.Update(ctx); err != nil {
+		c.Work = work
+		return err
+	}
+	return nil
+}
+
+// OverBudget reports whether more work was spent on the card than its
+// effort estimate.
+func (c *Card) OverBudget() bool {
+	return c.Effort > 0 && c.Work > c.Effort
+}
+
+// Move puts the card in list and records the time of the move in MovedAt.
+// In a manually ordered list the card goes to the end.
+func (c *Card) Move(ctx context.Context, list *List) error {
+	if c.ListId == list.Id {
+		return nil
+	}
+	listId, movedAt, pos := c.ListId, c.MovedAt, c.Pos
+	return RunTx(ctx, c.backend, func(be Backend) error {
+		if list.Order == ManualOrder {
+			cards, err := be.ListCards(ctx, list.Id)
+			if err != nil {
+				return err
+			}
+			list.sortCards(cards)
+			c.Pos, _ = posBetween(cardPositions(cards), len(cards))
+		}
+		c.ListId, c.MovedAt = list.Id, time.Now()
+		if err := be.UpdateCard(ctx, c); err != nil {
+			c.ListId, c.MovedAt, c.Pos = listId, movedAt, pos
+			return err
+		}
+		return nil
+	})
+}
+
 func (c *Card) HasHigherPriority(o *Card) bool {
 	switch {
 	case c.Value == o.Value:
@@ -135,6 +460,15 @@ func (c *Card) HasHigherPriority(o *Card) bool {
 	}
 }
 
+// ShortId is the id prefix shown to people, long enough to address a card
+// on any realistic board.
+func ShortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
 type Label struct {
 	Color, Name string
 }
@@ -144,6 +478,8 @@ type BoardHandler interface {
 	GetBoard(context.Context, string) (*Board, error)
 	AddBoard(context.Context, *Board) error
 	UpdateBoard(context.Context, *Board) error
+	// DeleteBoard removes the board together with all of its lists and
+	// their cards atomically.
 	DeleteBoard(context.Context, string) error
 }
 
@@ -152,6 +488,8 @@ type ListHandler interface {
 	GetList(context.Context, string) (*List, error)
 	AddList(context.Context, *List) error
 	UpdateList(context.Context, *List) error
+	// DeleteList removes the list together with all of its cards
+	// atomically.
 	DeleteList(context.Context, string) error
 }
 
@@ -168,3 +506,70 @@ type Backend interface {
 	ListHandler
 	CardHandler
 }
+
+// Transactor is implemented by backends that can apply several operations
+// as a single unit of work. Objects obtained from the Backend passed to fn
+// are bound to the transaction and must not be used after Tx returns.
+type Transactor interface {
+	Tx(context.Context, func(Backend) error) error
+}
+
+// RunTx calls fn within a transaction when be is a Transactor, and directly
+// against be otherwise.
+func RunTx(ctx context.Context, be Backend, fn func(Backend) error) error {
+	if t, ok := be.(Transactor); ok {
+		return t.Tx(ctx, fn)
+	}
+	return fn(be)
+}
+
+type Op int
+
+const (
+	Created Op = iota + 1
+	Updated
+	Deleted
+)
+
+func (o Op) String() string {
+	switch o {
+	case Created:
+		return "created"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+type Kind string
+
+const (
+	BoardKind Kind = "board"
+	ListKind  Kind = "list"
+	CardKind  Kind = "card"
+)
+
+// Event describes a change to a board, list or card.
+type Event struct {
+	Op   Op
+	Kind Kind
+	Id   string
+	// ParentId is the board of a list or the list of a card. PrevParentId
+	// is set when an update moved the record to another parent.
+	ParentId, PrevParentId string
+	// Err is set on the last event of a feed that stopped because changes
+	// could no longer be read.
+	Err error
+}
+
+// Watcher is implemented by backends that can report changes made by any
+// client of the backend, such as another orga process or a script editing
+// cards while the TUI is open.
+type Watcher interface {
+	// Watch emits events for changes made after it is called until ctx is
+	// done or it fails, then closes the channel.
+	Watch(context.Context) (<-chan Event, error)
+}
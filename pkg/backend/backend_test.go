@@ -0,0 +1,175 @@
+package backend_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func names(t *testing.T, list *backend.List) []string {
+	t.Helper()
+	cards, err := list.Cards(context.TODO())
+	testutil.Ok(t, "list cards", err)
+	out := make([]string, len(cards))
+	for i, c := range cards {
+		out[i] = c.Name
+	}
+	return out
+}
+
+func equalNames(t *testing.T, want, got []string) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("want: %v, got: %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("want: %v, got: %v", want, got)
+		}
+	}
+}
+
+func newList(t *testing.T) *backend.List {
+	t.Helper()
+	ctx := context.TODO()
+	b := memory.New()
+	board := &backend.Board{Name: "order"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	board.SetBackend(b)
+	list := &backend.List{Name: "order"}
+	testutil.Ok(t, "add list", board.AddLists(ctx, list))
+	list.SetBackend(b)
+	testutil.Ok(t, "add cards", list.AddCards(ctx,
+		&backend.Card{Name: "low", Value: 1},
+		&backend.Card{Name: "high", Value: 3},
+		&backend.Card{Name: "mid", Value: 2},
+	))
+	return list
+}
+
+func TestList_Reorder(t *testing.T) {
+	ctx := context.TODO()
+	list := newList(t)
+	equalNames(t, []string{"high", "mid", "low"}, names(t, list))
+
+	testutil.Ok(t, "manual order", list.SetOrder(ctx, backend.ManualOrder))
+	equalNames(t, []string{"high", "mid", "low"}, names(t, list))
+
+	cards, err := list.Cards(ctx)
+	testutil.Ok(t, "list cards", err)
+	testutil.Ok(t, "reorder", list.Reorder(ctx, cards[2], 0))
+	equalNames(t, []string{"low", "high", "mid"}, names(t, list))
+
+	testutil.Ok(t, "add card", list.AddCards(ctx, &backend.Card{Name: "new", Value: 5}))
+	equalNames(t, []string{"low", "high", "mid", "new"}, names(t, list))
+
+	testutil.Ok(t, "priority order", list.SetOrder(ctx, backend.PriorityOrder))
+	equalNames(t, []string{"new", "high", "mid", "low"}, names(t, list))
+}
+
+func TestList_ReorderRebalance(t *testing.T) {
+	ctx := context.TODO()
+	list := newList(t)
+	testutil.Ok(t, "manual order", list.SetOrder(ctx, backend.ManualOrder))
+	// Repeatedly inserting between the same neighbours halves the gap each
+	// time, which must eventually spread the positions out again.
+	for i := 0; i < 64; i++ {
+		cards, err := list.Cards(ctx)
+		testutil.Ok(t, "list cards", err)
+		testutil.Ok(t, "reorder", list.Reorder(ctx, cards[len(cards)-1], 1))
+		cards, err = list.Cards(ctx)
+		testutil.Ok(t, "list cards", err)
+		for j := 1; j < len(cards); j++ {
+			if cards[j].Pos <= cards[j-1].Pos {
+				t.Fatalf("iteration %d: positions not increasing: %v <= %v", i, cards[j].Pos, cards[j-1].Pos)
+			}
+		}
+	}
+}
+
+func TestBoard_Reorder(t *testing.T) {
+	ctx := context.TODO()
+	b := memory.New()
+	board := &backend.Board{Name: "columns"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	board.SetBackend(b)
+	testutil.Ok(t, "add lists", board.AddLists(ctx,
+		&backend.List{Name: "todo", Pos: 0},
+		&backend.List{Name: "doing", Pos: 1},
+		&backend.List{Name: "done", Pos: 2},
+	))
+	lists, err := board.Lists(ctx)
+	testutil.Ok(t, "list lists", err)
+	testutil.Ok(t, "reorder", board.Reorder(ctx, lists[2], 1))
+	lists, err = board.Lists(ctx)
+	testutil.Ok(t, "list lists", err)
+	got := make([]string, len(lists))
+	for i, l := range lists {
+		got[i] = l.Name
+	}
+	equalNames(t, []string{"todo", "done", "doing"}, got)
+}
+
+func TestBoard_Labels(t *testing.T) {
+	ctx := context.TODO()
+	b := memory.New()
+	board := &backend.Board{Name: "labels"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	board.SetBackend(b)
+	testutil.Ok(t, "add label", board.AddLabel(ctx, backend.Label{Name: "bug", Color: "red"}))
+	testutil.Ok(t, "add label", board.AddLabel(ctx, backend.Label{Name: "ui", Color: "blue"}))
+	if err := board.AddLabel(ctx, backend.Label{Name: "bug"}); !errors.Is(err, backend.ErrConflict) {
+		t.Fatalf("want ErrConflict for a duplicate label, got: %v", err)
+	}
+	list := &backend.List{Name: "todo"}
+	testutil.Ok(t, "add list", board.AddLists(ctx, list))
+	list.SetBackend(b)
+	card := &backend.Card{Name: "crash", Labels: []backend.Label{{Name: "bug", Color: "red"}, {Name: "ui", Color: "blue"}}}
+	testutil.Ok(t, "add card", list.AddCards(ctx, card))
+
+	testutil.Ok(t, "update label", board.UpdateLabel(ctx, "bug", backend.Label{Name: "defect", Color: "orange"}))
+	got, err := b.GetCard(ctx, card.Id)
+	testutil.Ok(t, "get card", err)
+	if len(got.Labels) != 2 || got.Labels[0] != (backend.Label{Name: "defect", Color: "orange"}) {
+		t.Fatalf("card should be relabelled, got: %v", got.Labels)
+	}
+
+	testutil.Ok(t, "delete label", board.DeleteLabel(ctx, "ui"))
+	got, err = b.GetCard(ctx, card.Id)
+	testutil.Ok(t, "get card", err)
+	if len(got.Labels) != 1 || got.HasLabel("ui") {
+		t.Fatalf("label should be removed from the card, got: %v", got.Labels)
+	}
+	stored, err := b.GetBoard(ctx, board.Id)
+	testutil.Ok(t, "get board", err)
+	if len(stored.Labels) != 1 || stored.Labels[0].Name != "defect" {
+		t.Fatalf("want palette [defect], got: %v", stored.Labels)
+	}
+}
+
+func TestCard_LogWork(t *testing.T) {
+	ctx := context.TODO()
+	list := newList(t)
+	cards, err := list.Cards(ctx)
+	testutil.Ok(t, "list cards", err)
+	card := cards[0]
+	card.Effort = 2
+	testutil.Ok(t, "update card", card.Update(ctx))
+	testutil.Ok(t, "log work", card.LogWork(ctx, 3))
+	if !card.OverBudget() {
+		t.Fatalf("card with work %d and effort %d should be over budget", card.Work, card.Effort)
+	}
+	testutil.Ok(t, "unlog work", card.LogWork(ctx, -5))
+	if card.Work != 0 {
+		t.Fatalf("work should not go below zero, got %d", card.Work)
+	}
+	stale := *card
+	stale.Rev--
+	if err := stale.LogWork(ctx, 1); !errors.Is(err, backend.ErrConflict) || stale.Work != 0 {
+		t.Fatalf("want ErrConflict and work restored, got %v with work %d", err, stale.Work)
+	}
+}
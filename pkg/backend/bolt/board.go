@@ -4,14 +4,19 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/twistedogic/orga/pkg/backend"
 )
 
 func (b Backend) AddBoard(ctx context.Context, board *backend.Board) error {
-	id := uuid.NewString()
-	board.Id = id
-	return b.BoardHandler.Set(id, board)
+	return b.BoardHandler.update(func(tx *bolt.Tx) error {
+		if board.Id == "" {
+			board.Id = uuid.NewString()
+		}
+		board.Rev = 1
+		return b.BoardHandler.insert(tx, board.Id, board)
+	})
 }
 
 func (b Backend) GetBoard(ctx context.Context, id string) (*backend.Board, error) {
@@ -24,14 +29,24 @@ func (b Backend) GetBoard(ctx context.Context, id string) (*backend.Board, error
 }
 
 func (b Backend) UpdateBoard(ctx context.Context, board *backend.Board) error {
-	if _, err := b.GetBoard(ctx, board.Id); err != nil {
-		return err
-	}
-	return b.BoardHandler.Set(board.Id, board)
+	return b.BoardHandler.update(func(tx *bolt.Tx) error {
+		return b.BoardHandler.revise(tx, board.Id, &board.Rev, board)
+	})
 }
 
 func (b Backend) DeleteBoard(ctx context.Context, id string) error {
-	return b.BoardHandler.Delete(id)
+	return b.BoardHandler.update(func(tx *bolt.Tx) error {
+		lists, err := b.ListHandler.childKeys(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, list := range lists {
+			if err := b.deleteList(tx, list); err != nil {
+				return err
+			}
+		}
+		return b.BoardHandler.delete(tx, id)
+	})
 }
 
 func (b Backend) ListBoards(ctx context.Context) ([]*backend.Board, error) {
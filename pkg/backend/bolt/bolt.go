@@ -1,40 +1,91 @@
 package bolt
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	bolt "go.etcd.io/bbolt"
+
+	"github.com/twistedogic/orga/pkg/backend"
 )
 
 const (
 	boardBucketName = "board"
 	listBucketName  = "list"
 	cardBucketName  = "card"
+
+	boardListsIndexName = "board_lists"
+	listCardsIndexName  = "list_cards"
+)
+
+var (
+	boardListsIndex = NewIndex(boardListsIndexName, "BoardId")
+	listCardsIndex  = NewIndex(listCardsIndexName, "ListId")
+
+	// ErrInUse is returned when another process holds the lock on the
+	// database file for longer than OpenTimeout.
+	ErrInUse = errors.New("database is in use by another process")
+	// OpenTimeout is how long Open waits for the lock on the database file.
+	OpenTimeout = time.Second
 )
 
 type Backend struct {
 	BoardHandler, ListHandler, CardHandler Store
 }
 
+// NewWithDB migrates db to the latest schema version before use. The
+// backend keeps db open, so no other process can use the file meanwhile.
 func NewWithDB(db *bolt.DB) (*Backend, error) {
-	b := new(Backend)
-	b.BoardHandler = NewStore(boardBucketName, db)
-	if err := b.BoardHandler.Init(); err != nil {
-		return b, err
-	}
-	b.ListHandler = NewStore(listBucketName, db)
-	if err := b.ListHandler.Init(); err != nil {
-		return b, err
+	return newBackend(Held(db))
+}
+
+// New opens the file at path for each transaction, so several processes
+// can share it.
+func New(path string) (*Backend, error) {
+	return newBackend(Shared(path))
+}
+
+func newBackend(db *DB) (*Backend, error) {
+	b := &Backend{
+		BoardHandler: NewStore(boardBucketName, db).LogChanges(backend.BoardKind),
+		ListHandler:  NewIndexedStore(listBucketName, boardListsIndex, db).LogChanges(backend.ListKind),
+		CardHandler:  NewIndexedStore(cardBucketName, listCardsIndex, db).LogChanges(backend.CardKind),
 	}
-	b.CardHandler = NewStore(cardBucketName, db)
-	if err := b.CardHandler.Init(); err != nil {
+	err := db.with(func(db *bolt.DB) error {
+		_, err := Migrate(db, false)
+		return err
+	})
+	if err != nil {
 		return b, err
 	}
 	return b, nil
 }
 
-func New(path string) (*Backend, error) {
-	db, err := bolt.Open(path, 0600, nil)
-	if err != nil {
-		return nil, err
+// Tx runs fn against a Backend bound to a single bolt transaction, which is
+// committed when fn returns nil and rolled back otherwise. Calls nested in
+// an existing transaction join it.
+func (b Backend) Tx(ctx context.Context, fn func(backend.Backend) error) error {
+	if b.BoardHandler.tx != nil {
+		return fn(b)
+	}
+	return b.BoardHandler.Update(func(tx *bolt.Tx) error {
+		return fn(b.withTx(tx))
+	})
+}
+
+func checkParent(tx *bolt.Tx, parents Store, id string) error {
+	if !parents.exists(tx, id) {
+		return fmt.Errorf("%s %q does not exist: %w", parents.name, id, backend.ErrInvalid)
+	}
+	return nil
+}
+
+func (b Backend) withTx(tx *bolt.Tx) Backend {
+	return Backend{
+		BoardHandler: b.BoardHandler.WithTx(tx),
+		ListHandler:  b.ListHandler.WithTx(tx),
+		CardHandler:  b.CardHandler.WithTx(tx),
 	}
-	return NewWithDB(db)
 }
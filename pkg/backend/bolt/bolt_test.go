@@ -1,11 +1,17 @@
 package bolt
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twistedogic/orga/pkg/backend"
 	"github.com/twistedogic/orga/pkg/testutil"
 )
 
@@ -22,3 +28,216 @@ func Test_Backend(t *testing.T) {
 	}
 	testutil.TestBackend(t, b)
 }
+
+func Test_IndexRebuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "test_db")
+	defer os.RemoveAll(dir)
+	db, err := bolt.Open(f, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Simulate a database written before the indexes existed.
+	lists, cards := NewStore(listBucketName, Held(db)), NewStore(cardBucketName, Held(db))
+	testutil.Ok(t, "init lists", lists.Init())
+	testutil.Ok(t, "init cards", cards.Init())
+	testutil.Ok(t, "set list", lists.Set("l1", &backend.List{Id: "l1", BoardId: "b1"}))
+	testutil.Ok(t, "set card", cards.Set("c1", &backend.Card{Id: "c1", ListId: "l1"}))
+	testutil.Ok(t, "set card", cards.Set("c2", &backend.Card{Id: "c2", ListId: "l2"}))
+
+	b, err := NewWithDB(db)
+	testutil.Ok(t, "open", err)
+	ctx := context.TODO()
+	gotLists, err := b.ListLists(ctx, "b1")
+	testutil.Ok(t, "list lists", err)
+	if len(gotLists) != 1 || gotLists[0].Id != "l1" {
+		t.Fatalf("want list l1, got: %v", gotLists)
+	}
+	gotCards, err := b.ListCards(ctx, "l1")
+	testutil.Ok(t, "list cards", err)
+	if len(gotCards) != 1 || gotCards[0].Id != "c1" {
+		t.Fatalf("want card c1, got: %v", gotCards)
+	}
+}
+
+func Test_Migrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt_migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "test_db")
+	defer os.RemoveAll(dir)
+	db, err := bolt.Open(f, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cards := NewStore(cardBucketName, Held(db))
+	testutil.Ok(t, "init cards", cards.Init())
+	testutil.Ok(t, "set card", cards.Set("c1", map[string]string{"Id": "c1", "ListId": "l1"}))
+
+	results, err := Migrate(db, true)
+	testutil.Ok(t, "dry run", err)
+	if len(results) != len(Migrations) {
+		t.Fatalf("want %d pending migrations, got: %v", len(Migrations), results)
+	}
+	for _, r := range results {
+		if r.Description == Migrations[2].Description && r.Changed != 1 {
+			t.Fatalf("want revision stamped on 1 record, got: %v", r)
+		}
+	}
+	version, err := SchemaVersion(db)
+	testutil.Ok(t, "schema version", err)
+	if version != 0 {
+		t.Fatalf("dry run should not change schema version, got: %d", version)
+	}
+
+	_, err = Migrate(db, false)
+	testutil.Ok(t, "migrate", err)
+	version, err = SchemaVersion(db)
+	testutil.Ok(t, "schema version", err)
+	if version != len(Migrations) {
+		t.Fatalf("want schema version %d, got: %d", len(Migrations), version)
+	}
+	card := new(backend.Card)
+	testutil.Ok(t, "get card", cards.Get("c1", card))
+	if card.Rev != 1 {
+		t.Fatalf("want revision 1, got: %d", card.Rev)
+	}
+	results, err = Migrate(db, false)
+	testutil.Ok(t, "migrate again", err)
+	if len(results) != 0 {
+		t.Fatalf("want no pending migrations, got: %v", results)
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt_watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "test_db")
+	defer os.RemoveAll(dir)
+	b, err := New(f)
+	testutil.Ok(t, "open", err)
+	interval := PollInterval
+	PollInterval = time.Millisecond
+	defer func() { PollInterval = interval }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := b.Watch(ctx)
+	testutil.Ok(t, "watch", err)
+
+	board := &backend.Board{Name: "watch"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	todo, done := &backend.List{Name: "todo", BoardId: board.Id}, &backend.List{Name: "done", BoardId: board.Id}
+	testutil.Ok(t, "add list", b.AddList(ctx, todo))
+	testutil.Ok(t, "add list", b.AddList(ctx, done))
+	card := &backend.Card{Name: "watch", ListId: todo.Id}
+	testutil.Ok(t, "add card", b.AddCard(ctx, card))
+	card.ListId = done.Id
+	testutil.Ok(t, "move card", b.UpdateCard(ctx, card))
+	testutil.Ok(t, "delete list", b.DeleteList(ctx, done.Id))
+
+	want := []backend.Event{
+		{Op: backend.Created, Kind: backend.BoardKind, Id: board.Id},
+		{Op: backend.Created, Kind: backend.ListKind, Id: todo.Id, ParentId: board.Id},
+		{Op: backend.Created, Kind: backend.ListKind, Id: done.Id, ParentId: board.Id},
+		{Op: backend.Created, Kind: backend.CardKind, Id: card.Id, ParentId: todo.Id},
+		{Op: backend.Updated, Kind: backend.CardKind, Id: card.Id, ParentId: done.Id, PrevParentId: todo.Id},
+		{Op: backend.Deleted, Kind: backend.CardKind, Id: card.Id, ParentId: done.Id},
+		{Op: backend.Deleted, Kind: backend.ListKind, Id: done.Id, ParentId: board.Id},
+	}
+	for i, w := range want {
+		select {
+		case got := <-events:
+			if got != w {
+				t.Fatalf("event %d: want: %+v, got: %+v", i, w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timed out waiting for %+v", i, w)
+		}
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func Test_WatchShared(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "test_db")
+	watched, err := New(f)
+	testutil.Ok(t, "open", err)
+	other, err := New(f)
+	testutil.Ok(t, "open again", err)
+	interval := PollInterval
+	PollInterval = time.Millisecond
+	defer func() { PollInterval = interval }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := watched.Watch(ctx)
+	testutil.Ok(t, "watch", err)
+	board := &backend.Board{Name: "other"}
+	testutil.Ok(t, "add board", other.AddBoard(ctx, board))
+	want := backend.Event{Op: backend.Created, Kind: backend.BoardKind, Id: board.Id}
+	select {
+	case got := <-events:
+		if got != want {
+			t.Fatalf("want: %+v, got: %+v", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %+v", want)
+	}
+}
+
+func Test_WatchError(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "test_db")
+	b, err := New(f)
+	testutil.Ok(t, "open", err)
+	interval := PollInterval
+	PollInterval = time.Millisecond
+	defer func() { PollInterval = interval }()
+
+	events, err := b.Watch(context.Background())
+	testutil.Ok(t, "watch", err)
+	err = b.BoardHandler.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket([]byte(changeBucketName))
+	})
+	testutil.Ok(t, "delete change log", err)
+	select {
+	case e := <-events:
+		if e.Err == nil {
+			t.Fatalf("want an error event, got: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the error")
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("want the feed closed after the error")
+	}
+}
+
+func Test_Open(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "test_db")
+	_, err := New(f)
+	testutil.Ok(t, "open", err)
+	b, err := New(f)
+	testutil.Ok(t, "open again", err)
+	timeout := OpenTimeout
+	OpenTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { OpenTimeout = timeout })
+	db, err := Open(f)
+	testutil.Ok(t, "lock", err)
+	if err := b.AddBoard(context.Background(), &backend.Board{Name: "locked"}); !errors.Is(err, ErrInUse) {
+		t.Fatalf("want ErrInUse, got %v", err)
+	}
+	testutil.Ok(t, "close", db.Close())
+	testutil.Ok(t, "add board", b.AddBoard(context.Background(), &backend.Board{Name: "unlocked"}))
+}
@@ -2,16 +2,27 @@ package bolt
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/twistedogic/orga/pkg/backend"
 )
 
 func (b Backend) AddCard(ctx context.Context, card *backend.Card) error {
-	id := uuid.NewString()
-	card.Id = id
-	return b.CardHandler.Set(id, card)
+	return b.CardHandler.update(func(tx *bolt.Tx) error {
+		if err := checkParent(tx, b.ListHandler, card.ListId); err != nil {
+			return err
+		}
+		if card.Id == "" {
+			card.Id = uuid.NewString()
+		}
+		card.Rev = 1
+		card.LastUpdate = time.Now()
+		return b.CardHandler.insert(tx, card.Id, card)
+	})
 }
 
 func (b Backend) GetCard(ctx context.Context, id string) (*backend.Card, error) {
@@ -24,10 +35,18 @@ func (b Backend) GetCard(ctx context.Context, id string) (*backend.Card, error)
 }
 
 func (b Backend) UpdateCard(ctx context.Context, card *backend.Card) error {
-	if _, err := b.GetCard(ctx, card.Id); err != nil {
-		return err
-	}
-	return b.CardHandler.Set(card.Id, card)
+	return b.CardHandler.update(func(tx *bolt.Tx) error {
+		if err := checkParent(tx, b.ListHandler, card.ListId); err != nil {
+			return err
+		}
+		last := card.LastUpdate
+		card.LastUpdate = time.Now()
+		if err := b.CardHandler.revise(tx, card.Id, &card.Rev, card); err != nil {
+			card.LastUpdate = last
+			return err
+		}
+		return nil
+	})
 }
 
 func (b Backend) DeleteCard(ctx context.Context, id string) error {
@@ -35,19 +54,18 @@ func (b Backend) DeleteCard(ctx context.Context, id string) error {
 }
 
 func (b Backend) ListCards(ctx context.Context, listId string) ([]*backend.Card, error) {
-	ids, err := b.CardHandler.List()
+	cards := make([]*backend.Card, 0)
+	err := b.CardHandler.Children(listId, func(_ string, value []byte) error {
+		card := new(backend.Card)
+		if err := json.Unmarshal(value, card); err != nil {
+			return err
+		}
+		card.SetBackend(b)
+		cards = append(cards, card)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	cards := make([]*backend.Card, 0, len(ids))
-	for _, id := range ids {
-		card, err := b.GetCard(ctx, id)
-		if err != nil {
-			return nil, err
-		}
-		if card.ListId == listId {
-			cards = append(cards, card)
-		}
-	}
 	return cards, nil
 }
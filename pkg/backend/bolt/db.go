@@ -0,0 +1,112 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DB runs the transactions of the stores. bbolt locks the database file
+// for as long as it is open, so a DB made with Shared opens the file for
+// each transaction and closes it once no transaction of the process is
+// running, leaving other processes free to use it in between.
+type DB struct {
+	path string
+	mu   sync.Mutex
+	db   *bolt.DB
+	refs int
+	// reverts holds, per open write transaction, the functions taking back
+	// the changes made to the caller's records if it is rolled back.
+	reverts map[*bolt.Tx][]func()
+}
+
+// Held returns a DB running every transaction on db, which stays open and
+// locked until the caller closes it.
+func Held(db *bolt.DB) *DB {
+	return &DB{db: db}
+}
+
+// Shared returns a DB opening the file at path for each transaction.
+func Shared(path string) *DB {
+	return &DB{path: path}
+}
+
+// Open opens the database file at path, failing with ErrInUse rather than
+// waiting more than OpenTimeout for another process to close it.
+func Open(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: OpenTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s: %w", path, ErrInUse)
+	}
+	return db, err
+}
+
+// with calls fn with the open database, opening the file first if no other
+// transaction of the process has it open.
+func (d *DB) with(fn func(*bolt.DB) error) error {
+	d.mu.Lock()
+	if d.db == nil {
+		db, err := Open(d.path)
+		if err != nil {
+			d.mu.Unlock()
+			return err
+		}
+		d.db = db
+	}
+	d.refs++
+	db := d.db
+	d.mu.Unlock()
+
+	err := fn(db)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refs--
+	if d.refs == 0 && d.path != "" {
+		d.db = nil
+		if cerr := db.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (d *DB) View(fn func(*bolt.Tx) error) error {
+	return d.with(func(db *bolt.DB) error {
+		return db.View(fn)
+	})
+}
+
+// Update runs fn in a write transaction, calling the functions given to
+// onRollback for it in reverse order if it is not committed.
+func (d *DB) Update(fn func(*bolt.Tx) error) error {
+	return d.with(func(db *bolt.DB) error {
+		var tx *bolt.Tx
+		err := db.Update(func(t *bolt.Tx) error {
+			tx = t
+			return fn(t)
+		})
+		d.mu.Lock()
+		reverts := d.reverts[tx]
+		delete(d.reverts, tx)
+		d.mu.Unlock()
+		if err != nil {
+			for i := len(reverts) - 1; i >= 0; i-- {
+				reverts[i]()
+			}
+		}
+		return err
+	})
+}
+
+// onRollback calls fn if tx, which was begun by Update, is rolled back.
+func (d *DB) onRollback(tx *bolt.Tx, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.reverts == nil {
+		d.reverts = make(map[*bolt.Tx][]func())
+	}
+	d.reverts[tx] = append(d.reverts[tx], fn)
+}
@@ -2,16 +2,25 @@ package bolt
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/twistedogic/orga/pkg/backend"
 )
 
 func (b Backend) AddList(ctx context.Context, list *backend.List) error {
-	id := uuid.NewString()
-	list.Id = id
-	return b.ListHandler.Set(id, list)
+	return b.ListHandler.update(func(tx *bolt.Tx) error {
+		if err := checkParent(tx, b.BoardHandler, list.BoardId); err != nil {
+			return err
+		}
+		if list.Id == "" {
+			list.Id = uuid.NewString()
+		}
+		list.Rev = 1
+		return b.ListHandler.insert(tx, list.Id, list)
+	})
 }
 
 func (b Backend) GetList(ctx context.Context, id string) (*backend.List, error) {
@@ -24,30 +33,46 @@ func (b Backend) GetList(ctx context.Context, id string) (*backend.List, error)
 }
 
 func (b Backend) UpdateList(ctx context.Context, list *backend.List) error {
-	if _, err := b.GetList(ctx, list.Id); err != nil {
-		return err
-	}
-	return b.ListHandler.Set(list.Id, list)
+	return b.ListHandler.update(func(tx *bolt.Tx) error {
+		if err := checkParent(tx, b.BoardHandler, list.BoardId); err != nil {
+			return err
+		}
+		return b.ListHandler.revise(tx, list.Id, &list.Rev, list)
+	})
 }
 
 func (b Backend) DeleteList(ctx context.Context, id string) error {
-	return b.ListHandler.Delete(id)
+	return b.ListHandler.update(func(tx *bolt.Tx) error {
+		return b.deleteList(tx, id)
+	})
 }
 
-func (b Backend) ListLists(ctx context.Context, boardId string) ([]*backend.List, error) {
-	ids, err := b.ListHandler.List()
+func (b Backend) deleteList(tx *bolt.Tx, id string) error {
+	cards, err := b.CardHandler.childKeys(tx, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	lists := make([]*backend.List, 0, len(ids))
-	for _, id := range ids {
-		list, err := b.GetList(ctx, id)
-		if err != nil {
-			return nil, err
+	for _, card := range cards {
+		if err := b.CardHandler.delete(tx, card); err != nil {
+			return err
 		}
-		if list.BoardId == boardId {
-			lists = append(lists, list)
+	}
+	return b.ListHandler.delete(tx, id)
+}
+
+func (b Backend) ListLists(ctx context.Context, boardId string) ([]*backend.List, error) {
+	lists := make([]*backend.List, 0)
+	err := b.ListHandler.Children(boardId, func(_ string, value []byte) error {
+		list := new(backend.List)
+		if err := json.Unmarshal(value, list); err != nil {
+			return err
 		}
+		list.SetBackend(b)
+		lists = append(lists, list)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return lists, nil
 }
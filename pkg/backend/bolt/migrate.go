@@ -0,0 +1,178 @@
+package bolt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	metaBucketName   = "meta"
+	schemaVersionKey = "schema_version"
+)
+
+// Migration upgrades the database by one schema version. Apply runs inside
+// the transaction shared by all pending migrations and returns the number
+// of records it changed.
+type Migration struct {
+	Description string
+	Apply       func(tx *bolt.Tx) (int, error)
+}
+
+// Migrations is the ordered registry of schema changes; the schema version
+// of a database is the number of entries applied to it. Entries must never
+// be edited or reordered once released; append new ones instead.
+var Migrations = []Migration{
+	{
+		Description: "create board, list and card buckets",
+		Apply: func(tx *bolt.Tx) (int, error) {
+			for _, name := range []string{boardBucketName, listBucketName, cardBucketName} {
+				if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+					return 0, err
+				}
+			}
+			return 0, nil
+		},
+	},
+	{
+		Description: "index lists by board and cards by list",
+		Apply: func(tx *bolt.Tx) (int, error) {
+			lists, err := boardListsIndex.rebuild(tx, tx.Bucket([]byte(listBucketName)))
+			if err != nil {
+				return 0, err
+			}
+			cards, err := listCardsIndex.rebuild(tx, tx.Bucket([]byte(cardBucketName)))
+			return lists + cards, err
+		},
+	},
+	{
+		Description: "stamp revision 1 on records written before revisions",
+		Apply: func(tx *bolt.Tx) (int, error) {
+			n := 0
+			for _, name := range []string{boardBucketName, listBucketName, cardBucketName} {
+				changed, err := stampRevisions(tx.Bucket([]byte(name)))
+				if err != nil {
+					return n, fmt.Errorf("%s: %w", name, err)
+				}
+				n += changed
+			}
+			return n, nil
+		},
+	},
+	{
+		Description: "create change log bucket",
+		Apply: func(tx *bolt.Tx) (int, error) {
+			_, err := tx.CreateBucketIfNotExists([]byte(changeBucketName))
+			return 0, err
+		},
+	},
+}
+
+func stampRevisions(b *bolt.Bucket) (int, error) {
+	updates := make(map[string][]byte)
+	err := b.ForEach(func(k, v []byte) error {
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(v, &fields); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		if rev, ok := fields["Rev"]; ok && string(rev) != "0" {
+			return nil
+		}
+		fields["Rev"] = json.RawMessage("1")
+		value, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		updates[string(k)] = value
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range updates {
+		if err := b.Put([]byte(k), v); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
+
+// MigrationResult reports a migration applied by Migrate.
+type MigrationResult struct {
+	Version     int
+	Description string
+	Changed     int
+}
+
+var errDryRun = errors.New("dry run")
+
+// SchemaVersion returns the number of migrations applied to db.
+func SchemaVersion(db *bolt.DB) (int, error) {
+	version := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = schemaVersion(tx)
+		return err
+	})
+	return version, err
+}
+
+func schemaVersion(tx *bolt.Tx) (int, error) {
+	meta := tx.Bucket([]byte(metaBucketName))
+	if meta == nil {
+		return 0, nil
+	}
+	v := meta.Get([]byte(schemaVersionKey))
+	if v == nil {
+		return 0, nil
+	}
+	return strconv.Atoi(string(v))
+}
+
+// Migrate applies all pending migrations to db in a single transaction and
+// returns what they did. With dryRun the transaction is rolled back, so the
+// results only report what would change.
+func Migrate(db *bolt.DB, dryRun bool) ([]MigrationResult, error) {
+	results := make([]MigrationResult, 0)
+	err := db.Update(func(tx *bolt.Tx) error {
+		version, err := schemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		if version > len(Migrations) {
+			return fmt.Errorf("schema version %d is newer than the latest known version %d", version, len(Migrations))
+		}
+		if version == len(Migrations) {
+			return nil
+		}
+		for i, m := range Migrations[version:] {
+			changed, err := m.Apply(tx)
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", version+i+1, m.Description, err)
+			}
+			results = append(results, MigrationResult{
+				Version:     version + i + 1,
+				Description: m.Description,
+				Changed:     changed,
+			})
+		}
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucketName))
+		if err != nil {
+			return err
+		}
+		if err := meta.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(len(Migrations)))); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return results, err
+}
@@ -5,20 +5,131 @@ import (
 	"fmt"
 
 	bolt "go.etcd.io/bbolt"
+
+	"github.com/twistedogic/orga/pkg/backend"
 )
 
+// Index keeps a parent -> children mapping for a Store in its own bucket.
+// Each parent id is a nested bucket whose keys are the child ids.
+type Index struct {
+	name  []byte
+	field string
+}
+
+func NewIndex(name, field string) *Index {
+	return &Index{name: []byte(name), field: field}
+}
+
+func (x *Index) parent(value []byte) (string, error) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return "", err
+	}
+	parent, _ := fields[x.field].(string)
+	return parent, nil
+}
+
+func (x *Index) add(tx *bolt.Tx, key string, value []byte) error {
+	parent, err := x.parent(value)
+	if err != nil || parent == "" {
+		return err
+	}
+	b, err := tx.Bucket(x.name).CreateBucketIfNotExists([]byte(parent))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), nil)
+}
+
+func (x *Index) remove(tx *bolt.Tx, key string, value []byte) error {
+	parent, err := x.parent(value)
+	if err != nil || parent == "" {
+		return err
+	}
+	root := tx.Bucket(x.name)
+	b := root.Bucket([]byte(parent))
+	if b == nil {
+		return nil
+	}
+	if err := b.Delete([]byte(key)); err != nil {
+		return err
+	}
+	if k, _ := b.Cursor().First(); k == nil {
+		return root.DeleteBucket([]byte(parent))
+	}
+	return nil
+}
+
+// rebuild recreates the index from records, returning the number of
+// records indexed.
+func (x *Index) rebuild(tx *bolt.Tx, records *bolt.Bucket) (int, error) {
+	if tx.Bucket(x.name) != nil {
+		if err := tx.DeleteBucket(x.name); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.CreateBucket(x.name); err != nil {
+		return 0, err
+	}
+	n := 0
+	err := records.ForEach(func(k, v []byte) error {
+		n++
+		return x.add(tx, string(k), v)
+	})
+	return n, err
+}
+
 type Store struct {
-	name []byte
-	*bolt.DB
+	name  []byte
+	index *Index
+	kind  backend.Kind
+	tx    *bolt.Tx
+	*DB
 }
 
-func NewStore(name string, db *bolt.DB) Store {
+func NewStore(name string, db *DB) Store {
 	return Store{
 		name: []byte(name),
 		DB:   db,
 	}
 }
 
+func NewIndexedStore(name string, index *Index, db *DB) Store {
+	s := NewStore(name, db)
+	s.index = index
+	return s
+}
+
+// LogChanges returns a copy of the store that appends an event about a
+// record of kind to the change log on every write.
+func (s Store) LogChanges(kind backend.Kind) Store {
+	s.kind = kind
+	return s
+}
+
+// WithTx returns a copy of the store that runs every operation inside tx
+// instead of opening its own transaction.
+func (s Store) WithTx(tx *bolt.Tx) Store {
+	s.tx = tx
+	return s
+}
+
+func (s Store) view(fn func(*bolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.View(fn)
+}
+
+func (s Store) update(fn func(*bolt.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.Update(fn)
+}
+
+// Init creates the store bucket. Index buckets are created by the schema
+// migrations.
 func (s Store) Init() error {
 	return s.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists(s.name)
@@ -27,33 +138,151 @@ func (s Store) Init() error {
 }
 
 func (s Store) Get(key string, i interface{}) error {
-	return s.View(func(tx *bolt.Tx) error {
-		if b := tx.Bucket(s.name).Get([]byte(key)); b != nil {
-			return json.Unmarshal(b, i)
-		}
-		return fmt.Errorf("not key %s found", key)
+	return s.view(func(tx *bolt.Tx) error {
+		return s.get(tx, key, i)
 	})
 }
 
+func (s Store) get(tx *bolt.Tx, key string, i interface{}) error {
+	b := tx.Bucket(s.name).Get([]byte(key))
+	if b == nil {
+		return s.errorf(key, backend.ErrNotFound)
+	}
+	if err := json.Unmarshal(b, i); err != nil {
+		return s.errorf(key, err)
+	}
+	return nil
+}
+
+func (s Store) exists(tx *bolt.Tx, key string) bool {
+	return key != "" && tx.Bucket(s.name).Get([]byte(key)) != nil
+}
+
+// insert stores a new record, failing with backend.ErrConflict if key is
+// already taken.
+func (s Store) insert(tx *bolt.Tx, key string, i interface{}) error {
+	if s.exists(tx, key) {
+		return s.errorf(key, backend.ErrConflict)
+	}
+	return s.set(tx, key, i)
+}
+
+// revise replaces an existing record after checking that rev matches the
+// stored revision, then bumps rev. A stale rev fails with
+// backend.ErrConflict. rev is set back if the transaction is rolled back.
+func (s Store) revise(tx *bolt.Tx, key string, rev *uint64, i interface{}) error {
+	var old struct{ Rev uint64 }
+	if err := s.get(tx, key, &old); err != nil {
+		return err
+	}
+	if old.Rev != *rev {
+		return s.errorf(key, fmt.Errorf("%w: revision %d is stale, latest is %d", backend.ErrConflict, *rev, old.Rev))
+	}
+	*rev++
+	if err := s.set(tx, key, i); err != nil {
+		*rev--
+		return err
+	}
+	s.onRollback(tx, func() { *rev = old.Rev })
+	return nil
+}
+
+func (s Store) errorf(key string, err error) error {
+	return fmt.Errorf("%s %s: %w", s.name, key, err)
+}
+
 func (s Store) Set(key string, i interface{}) error {
-	return s.Update(func(tx *bolt.Tx) error {
-		b, err := json.Marshal(i)
-		if err != nil {
+	return s.update(func(tx *bolt.Tx) error {
+		return s.set(tx, key, i)
+	})
+}
+
+func (s Store) set(tx *bolt.Tx, key string, i interface{}) error {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	bucket := tx.Bucket(s.name)
+	old := bucket.Get([]byte(key))
+	if s.index != nil {
+		if old != nil {
+			if err := s.index.remove(tx, key, old); err != nil {
+				return err
+			}
+		}
+		if err := s.index.add(tx, key, b); err != nil {
 			return err
 		}
-		return tx.Bucket(s.name).Put([]byte(key), b)
-	})
+	}
+	if err := s.logChange(tx, key, old, b); err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), b)
 }
 
 func (s Store) Delete(key string) error {
-	return s.Update(func(tx *bolt.Tx) error {
-		return tx.Bucket(s.name).Delete([]byte(key))
+	return s.update(func(tx *bolt.Tx) error {
+		return s.delete(tx, key)
 	})
 }
 
+func (s Store) delete(tx *bolt.Tx, key string) error {
+	bucket := tx.Bucket(s.name)
+	old := bucket.Get([]byte(key))
+	if old == nil {
+		return s.errorf(key, backend.ErrNotFound)
+	}
+	if s.index != nil {
+		if err := s.index.remove(tx, key, old); err != nil {
+			return err
+		}
+	}
+	if err := s.logChange(tx, key, old, nil); err != nil {
+		return err
+	}
+	return bucket.Delete([]byte(key))
+}
+
+// logChange records the transition of key from old to value, either of
+// which is nil when the record is created or deleted.
+func (s Store) logChange(tx *bolt.Tx, key string, old, value []byte) error {
+	if s.kind == "" {
+		return nil
+	}
+	e := backend.Event{Kind: s.kind, Id: key}
+	switch {
+	case old == nil:
+		e.Op = backend.Created
+	case value == nil:
+		e.Op = backend.Deleted
+	default:
+		e.Op = backend.Updated
+	}
+	if s.index != nil {
+		parent, err := s.index.parent(value)
+		if value == nil {
+			parent, err = s.index.parent(old)
+		}
+		if err != nil {
+			return err
+		}
+		e.ParentId = parent
+		if e.Op == backend.Updated {
+			prev, err := s.index.parent(old)
+			if err != nil {
+				return err
+			}
+			if prev != parent {
+				e.PrevParentId = prev
+			}
+		}
+	}
+	return appendChange(tx, e)
+}
+
 func (s Store) List() ([]string, error) {
 	out := make([]string, 0)
-	err := s.View(func(tx *bolt.Tx) error {
+	err := s.view(func(tx *bolt.Tx) error {
 		return tx.Bucket(s.name).ForEach(func(k, _ []byte) error {
 			out = append(out, string(k))
 			return nil
@@ -61,3 +290,38 @@ func (s Store) List() ([]string, error) {
 	})
 	return out, err
 }
+
+// Children calls fn with every record indexed under parent in a single
+// read transaction.
+func (s Store) Children(parent string, fn func(key string, value []byte) error) error {
+	return s.view(func(tx *bolt.Tx) error {
+		return s.children(tx, parent, fn)
+	})
+}
+
+func (s Store) children(tx *bolt.Tx, parent string, fn func(key string, value []byte) error) error {
+	if s.index == nil {
+		return fmt.Errorf("store %s has no index", s.name)
+	}
+	b := tx.Bucket(s.index.name).Bucket([]byte(parent))
+	if b == nil {
+		return nil
+	}
+	records := tx.Bucket(s.name)
+	return b.ForEach(func(k, _ []byte) error {
+		v := records.Get(k)
+		if v == nil {
+			return fmt.Errorf("index %s: not key %s found", s.index.name, k)
+		}
+		return fn(string(k), v)
+	})
+}
+
+func (s Store) childKeys(tx *bolt.Tx, parent string) ([]string, error) {
+	keys := make([]string, 0)
+	err := s.children(tx, parent, func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
@@ -0,0 +1,121 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+const (
+	changeBucketName = "changes"
+	// changeLogSize is the number of most recent events kept in the log.
+	changeLogSize = 4096
+)
+
+// PollInterval is how often Watch checks the change log for new events.
+var PollInterval = 500 * time.Millisecond
+
+func seqKey(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
+
+// appendChange adds e to the change log, dropping the oldest event once
+// the log is full.
+func appendChange(tx *bolt.Tx, e backend.Event) error {
+	b := tx.Bucket([]byte(changeBucketName))
+	if b == nil {
+		// Not created yet while the schema is being migrated.
+		return nil
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(seqKey(seq), v); err != nil {
+		return err
+	}
+	if seq > changeLogSize {
+		return b.Delete(seqKey(seq - changeLogSize))
+	}
+	return nil
+}
+
+// Watch polls the change log for the writes made to the database file by
+// this or any other process. The feed carries on while another process
+// holds the file, and stops with an error event if the log cannot be read.
+func (b Backend) Watch(ctx context.Context) (<-chan backend.Event, error) {
+	var last uint64
+	err := b.BoardHandler.View(func(tx *bolt.Tx) error {
+		last = tx.Bucket([]byte(changeBucketName)).Sequence()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan backend.Event)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			events, seq, err := b.changesSince(last)
+			if errors.Is(err, ErrInUse) {
+				continue
+			}
+			if err != nil {
+				events = []backend.Event{{Err: err}}
+			}
+			last = seq
+			for _, e := range events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (b Backend) changesSince(seq uint64) ([]backend.Event, uint64, error) {
+	events := make([]backend.Event, 0)
+	err := b.BoardHandler.View(func(tx *bolt.Tx) error {
+		changes := tx.Bucket([]byte(changeBucketName))
+		if changes == nil {
+			return fmt.Errorf("bucket %s is missing", changeBucketName)
+		}
+		c := changes.Cursor()
+		for k, v := c.Seek(seqKey(seq + 1)); k != nil; k, v = c.Next() {
+			var e backend.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			events = append(events, e)
+			seq = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return events, seq, err
+}
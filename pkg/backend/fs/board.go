@@ -0,0 +1,112 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+type boardMeta struct {
+	Name   string      `yaml:"Name"`
+	Rev    uint64      `yaml:"Rev"`
+	Labels []labelMeta `yaml:"Labels,omitempty"`
+}
+
+func (b Backend) boardDir(id string) string {
+	return filepath.Join(b.root, id)
+}
+
+func (b Backend) readBoard(id string) (*backend.Board, error) {
+	if !validId.MatchString(id) {
+		return nil, errorf("board", id, backend.ErrNotFound)
+	}
+	var meta boardMeta
+	_, err := read(filepath.Join(b.boardDir(id), boardFile), &meta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errorf("board", id, backend.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	board := &backend.Board{Id: id, Name: meta.Name, Rev: meta.Rev, Labels: fromLabelMeta(meta.Labels)}
+	board.SetBackend(b)
+	return board, nil
+}
+
+func (b Backend) writeBoard(board *backend.Board) error {
+	meta := boardMeta{Name: board.Name, Rev: board.Rev, Labels: toLabelMeta(board.Labels)}
+	return write(filepath.Join(b.boardDir(board.Id), boardFile), meta, "")
+}
+
+func (b Backend) AddBoard(ctx context.Context, board *backend.Board) error {
+	defer b.lock()()
+	if err := newId("board", &board.Id); err != nil {
+		return err
+	}
+	dir := b.boardDir(board.Id)
+	if exists(dir) {
+		return errorf("board", board.Id, backend.ErrConflict)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	board.Rev = 1
+	if err := b.writeBoard(board); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+func (b Backend) GetBoard(ctx context.Context, id string) (*backend.Board, error) {
+	defer b.rlock()()
+	return b.readBoard(id)
+}
+
+func (b Backend) UpdateBoard(ctx context.Context, board *backend.Board) error {
+	defer b.lock()()
+	stored, err := b.readBoard(board.Id)
+	if err != nil {
+		return err
+	}
+	if stored.Rev != board.Rev {
+		return stale("board", board.Id, board.Rev, stored.Rev)
+	}
+	board.Rev++
+	if err := b.writeBoard(board); err != nil {
+		board.Rev--
+		return err
+	}
+	return nil
+}
+
+func (b Backend) DeleteBoard(ctx context.Context, id string) error {
+	defer b.lock()()
+	if _, err := b.readBoard(id); err != nil {
+		return err
+	}
+	return removeAll(b.boardDir(id))
+}
+
+func (b Backend) ListBoards(ctx context.Context) ([]*backend.Board, error) {
+	defer b.rlock()()
+	ids, err := subdirs(b.root)
+	if err != nil {
+		return nil, err
+	}
+	boards := make([]*backend.Board, 0, len(ids))
+	for _, id := range ids {
+		if !exists(filepath.Join(b.boardDir(id), boardFile)) {
+			continue
+		}
+		board, err := b.readBoard(id)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
@@ -0,0 +1,199 @@
+package fs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+type labelMeta struct {
+	Name  string `yaml:"Name"`
+	Color string `yaml:"Color,omitempty"`
+}
+
+func toLabelMeta(labels []backend.Label) []labelMeta {
+	var meta []labelMeta
+	for _, l := range labels {
+		meta = append(meta, labelMeta{Name: l.Name, Color: l.Color})
+	}
+	return meta
+}
+
+func fromLabelMeta(meta []labelMeta) []backend.Label {
+	var labels []backend.Label
+	for _, l := range meta {
+		labels = append(labels, backend.Label{Color: l.Color, Name: l.Name})
+	}
+	return labels
+}
+
+type cardMeta struct {
+	Name       string      `yaml:"Name"`
+	Value      int         `yaml:"Value"`
+	Effort     int         `yaml:"Effort"`
+	Work       int         `yaml:"Work"`
+	Labels     []labelMeta `yaml:"Labels,omitempty"`
+	Pos        float64     `yaml:"Pos"`
+	Rev        uint64      `yaml:"Rev"`
+	LastUpdate time.Time   `yaml:"LastUpdate"`
+	MovedAt    time.Time   `yaml:"MovedAt,omitempty"`
+}
+
+// cardPath locates the file of card id in any list, returning "" if there
+// is none.
+func (b Backend) cardPath(id string) (string, error) {
+	if !validId.MatchString(id) {
+		return "", nil
+	}
+	return b.find("*", "*", id+cardExt)
+}
+
+func (b Backend) readCard(path string) (*backend.Card, error) {
+	var meta cardMeta
+	description, err := read(path, &meta)
+	if err != nil {
+		return nil, err
+	}
+	card := &backend.Card{
+		LastUpdate:  meta.LastUpdate,
+		MovedAt:     meta.MovedAt,
+		ListId:      filepath.Base(filepath.Dir(path)),
+		Id:          strings.TrimSuffix(filepath.Base(path), cardExt),
+		Name:        meta.Name,
+		Description: description,
+		Value:       meta.Value,
+		Effort:      meta.Effort,
+		Work:        meta.Work,
+		Pos:         meta.Pos,
+		Rev:         meta.Rev,
+		Labels:      fromLabelMeta(meta.Labels),
+	}
+	card.SetBackend(b)
+	return card, nil
+}
+
+func writeCard(dir string, card *backend.Card) error {
+	meta := cardMeta{
+		Name:       card.Name,
+		Value:      card.Value,
+		Effort:     card.Effort,
+		Work:       card.Work,
+		Pos:        card.Pos,
+		Rev:        card.Rev,
+		LastUpdate: card.LastUpdate,
+		MovedAt:    card.MovedAt,
+		Labels:     toLabelMeta(card.Labels),
+	}
+	return write(filepath.Join(dir, card.Id+cardExt), meta, card.Description)
+}
+
+func (b Backend) parentList(id string) (string, error) {
+	dir, err := b.listDir(id)
+	if err == nil && dir == "" {
+		err = missingParent("list", id)
+	}
+	return dir, err
+}
+
+func (b Backend) getCard(id string) (string, *backend.Card, error) {
+	path, err := b.cardPath(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if path == "" {
+		return "", nil, errorf("card", id, backend.ErrNotFound)
+	}
+	card, err := b.readCard(path)
+	return path, card, err
+}
+
+func (b Backend) AddCard(ctx context.Context, card *backend.Card) error {
+	defer b.lock()()
+	dir, err := b.parentList(card.ListId)
+	if err != nil {
+		return err
+	}
+	if err := newId("card", &card.Id); err != nil {
+		return err
+	}
+	if path, err := b.cardPath(card.Id); err != nil || path != "" {
+		if err == nil {
+			err = errorf("card", card.Id, backend.ErrConflict)
+		}
+		return err
+	}
+	card.Rev = 1
+	card.LastUpdate = time.Now()
+	return writeCard(dir, card)
+}
+
+func (b Backend) GetCard(ctx context.Context, id string) (*backend.Card, error) {
+	defer b.rlock()()
+	_, card, err := b.getCard(id)
+	return card, err
+}
+
+func (b Backend) UpdateCard(ctx context.Context, card *backend.Card) error {
+	defer b.lock()()
+	dir, err := b.parentList(card.ListId)
+	if err != nil {
+		return err
+	}
+	path, stored, err := b.getCard(card.Id)
+	if err != nil {
+		return err
+	}
+	if stored.Rev != card.Rev {
+		return stale("card", card.Id, card.Rev, stored.Rev)
+	}
+	rev, last := card.Rev, card.LastUpdate
+	card.Rev++
+	card.LastUpdate = time.Now()
+	if err := writeCard(dir, card); err != nil {
+		card.Rev, card.LastUpdate = rev, last
+		return err
+	}
+	if filepath.Dir(path) != dir {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func (b Backend) DeleteCard(ctx context.Context, id string) error {
+	defer b.lock()()
+	path, _, err := b.getCard(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (b Backend) ListCards(ctx context.Context, listId string) ([]*backend.Card, error) {
+	defer b.rlock()()
+	cards := make([]*backend.Card, 0)
+	dir, err := b.listDir(listId)
+	if dir == "" || err != nil {
+		return cards, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), cardExt)
+		if e.IsDir() || !strings.HasSuffix(e.Name(), cardExt) || !validId.MatchString(id) {
+			continue
+		}
+		card, err := b.readCard(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
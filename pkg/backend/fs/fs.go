@@ -0,0 +1,162 @@
+// Package fs stores boards as plain files that can be kept under version
+// control. The root directory holds one directory per board, each board
+// directory holds one directory per list, and each list directory holds one
+// Markdown file per card:
+//
+//	<root>/<board id>/_board.md
+//	<root>/<board id>/<list id>/_list.md
+//	<root>/<board id>/<list id>/<card id>.md
+//
+// Every file starts with YAML front-matter carrying the record fields; the
+// body of a card file is its description.
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/frontmatter"
+)
+
+const (
+	boardFile = "_board.md"
+	listFile  = "_list.md"
+	cardExt   = ".md"
+)
+
+// validId keeps ids usable as file names and disjoint from the metadata
+// files, which start with an underscore.
+var validId = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// Backend reads and writes the files under a root directory. Writes are
+// serialised within a process; concurrent writers in other processes are
+// not coordinated.
+type Backend struct {
+	root string
+	mu   *sync.RWMutex
+}
+
+func New(root string) (*Backend, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &Backend{root: root, mu: new(sync.RWMutex)}, nil
+}
+
+func errorf(kind, id string, err error) error {
+	return fmt.Errorf("%s %s: %w", kind, id, err)
+}
+
+func stale(kind, id string, rev, latest uint64) error {
+	return errorf(kind, id, fmt.Errorf("%w: revision %d is stale, latest is %d", backend.ErrConflict, rev, latest))
+}
+
+func missingParent(kind, id string) error {
+	return fmt.Errorf("%s %q does not exist: %w", kind, id, backend.ErrInvalid)
+}
+
+// newId assigns a fresh id when none is set and validates the result.
+func newId(kind string, id *string) error {
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+	if !validId.MatchString(*id) {
+		return errorf(kind, *id, fmt.Errorf("%w: id must only contain letters, digits and dashes", backend.ErrInvalid))
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// find returns the single path matching pattern, or "" when there is none.
+func (b Backend) find(pattern ...string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(append([]string{b.root}, pattern...)...))
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+	return matches[0], nil
+}
+
+func read(path string, meta interface{}) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	body, err := frontmatter.Unmarshal(data, meta)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return body, nil
+}
+
+// write replaces path atomically so readers never see a partial file.
+func write(path string, meta interface{}, body string) error {
+	data, err := frontmatter.Marshal(meta, body)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// removeAll moves dir out of sight before deleting it, so a failure
+// part-way never leaves a half-deleted board or list behind.
+func removeAll(dir string) error {
+	trash := filepath.Join(filepath.Dir(dir), ".trash-"+filepath.Base(dir))
+	if err := os.Rename(dir, trash); err != nil {
+		return err
+	}
+	return os.RemoveAll(trash)
+}
+
+// subdirs returns the names of the directories in dir with a valid id,
+// or nothing if dir does not exist.
+func subdirs(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && validId.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (b Backend) lock() func() {
+	b.mu.Lock()
+	return b.mu.Unlock
+}
+
+func (b Backend) rlock() func() {
+	b.mu.RLock()
+	return b.mu.RUnlock
+}
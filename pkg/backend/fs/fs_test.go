@@ -0,0 +1,66 @@
+package fs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func Test_Backend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.TestBackend(t, b)
+}
+
+func Test_CardFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b, err := New(dir)
+	testutil.Ok(t, "open", err)
+	ctx := context.TODO()
+	board := &backend.Board{Name: "files"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	list := &backend.List{Name: "files", BoardId: board.Id}
+	testutil.Ok(t, "add list", b.AddList(ctx, list))
+	card := &backend.Card{
+		Name:        "files",
+		ListId:      list.Id,
+		Description: "# Notes\n\n- first",
+		Value:       3,
+		Labels:      []backend.Label{{Color: "red", Name: "bug"}},
+	}
+	testutil.Ok(t, "add card", b.AddCard(ctx, card))
+
+	path := filepath.Join(dir, board.Id, list.Id, card.Id+".md")
+	data, err := ioutil.ReadFile(path)
+	testutil.Ok(t, "read card file", err)
+	for _, want := range []string{"Value: 3\n", "- Name: bug\n", "---\n# Notes\n\n- first\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("want %q in card file:\n%s", want, data)
+		}
+	}
+
+	edited := strings.Replace(string(data), "Value: 3", "Value: 5", 1)
+	testutil.Ok(t, "edit card file", ioutil.WriteFile(path, []byte(edited), 0644))
+	got, err := b.GetCard(ctx, card.Id)
+	testutil.Ok(t, "get card", err)
+	if got.Value != 5 || got.Description != card.Description || len(got.Labels) != 1 {
+		t.Fatalf("want edited card, got: %+v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+type listMeta struct {
+	Name  string        `yaml:"Name"`
+	Pos   float64       `yaml:"Pos"`
+	Rev   uint64        `yaml:"Rev"`
+	Order backend.Order `yaml:"Order,omitempty"`
+}
+
+// listDir locates the directory of list id in any board, returning "" if
+// there is none.
+func (b Backend) listDir(id string) (string, error) {
+	if !validId.MatchString(id) {
+		return "", nil
+	}
+	path, err := b.find("*", id, listFile)
+	if path == "" || err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+func (b Backend) readList(dir string) (*backend.List, error) {
+	var meta listMeta
+	if _, err := read(filepath.Join(dir, listFile), &meta); err != nil {
+		return nil, err
+	}
+	list := &backend.List{
+		BoardId: filepath.Base(filepath.Dir(dir)),
+		Id:      filepath.Base(dir),
+		Name:    meta.Name,
+		Pos:     meta.Pos,
+		Rev:     meta.Rev,
+		Order:   meta.Order,
+	}
+	list.SetBackend(b)
+	return list, nil
+}
+
+func (b Backend) writeList(list *backend.List) error {
+	meta := listMeta{Name: list.Name, Pos: list.Pos, Rev: list.Rev, Order: list.Order}
+	return write(filepath.Join(b.boardDir(list.BoardId), list.Id, listFile), meta, "")
+}
+
+func (b Backend) checkBoard(id string) error {
+	if !validId.MatchString(id) || !exists(filepath.Join(b.boardDir(id), boardFile)) {
+		return missingParent("board", id)
+	}
+	return nil
+}
+
+func (b Backend) AddList(ctx context.Context, list *backend.List) error {
+	defer b.lock()()
+	if err := b.checkBoard(list.BoardId); err != nil {
+		return err
+	}
+	if err := newId("list", &list.Id); err != nil {
+		return err
+	}
+	if dir, err := b.listDir(list.Id); err != nil || dir != "" {
+		if err == nil {
+			err = errorf("list", list.Id, backend.ErrConflict)
+		}
+		return err
+	}
+	dir := filepath.Join(b.boardDir(list.BoardId), list.Id)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	list.Rev = 1
+	if err := b.writeList(list); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+func (b Backend) getList(id string) (string, *backend.List, error) {
+	dir, err := b.listDir(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if dir == "" {
+		return "", nil, errorf("list", id, backend.ErrNotFound)
+	}
+	list, err := b.readList(dir)
+	return dir, list, err
+}
+
+func (b Backend) GetList(ctx context.Context, id string) (*backend.List, error) {
+	defer b.rlock()()
+	_, list, err := b.getList(id)
+	return list, err
+}
+
+func (b Backend) UpdateList(ctx context.Context, list *backend.List) error {
+	defer b.lock()()
+	if err := b.checkBoard(list.BoardId); err != nil {
+		return err
+	}
+	dir, stored, err := b.getList(list.Id)
+	if err != nil {
+		return err
+	}
+	if stored.Rev != list.Rev {
+		return stale("list", list.Id, list.Rev, stored.Rev)
+	}
+	if stored.BoardId != list.BoardId {
+		if err := os.Rename(dir, filepath.Join(b.boardDir(list.BoardId), list.Id)); err != nil {
+			return err
+		}
+	}
+	list.Rev++
+	if err := b.writeList(list); err != nil {
+		list.Rev--
+		return err
+	}
+	return nil
+}
+
+func (b Backend) DeleteList(ctx context.Context, id string) error {
+	defer b.lock()()
+	dir, _, err := b.getList(id)
+	if err != nil {
+		return err
+	}
+	return removeAll(dir)
+}
+
+func (b Backend) ListLists(ctx context.Context, boardId string) ([]*backend.List, error) {
+	defer b.rlock()()
+	lists := make([]*backend.List, 0)
+	if !validId.MatchString(boardId) {
+		return lists, nil
+	}
+	ids, err := subdirs(b.boardDir(boardId))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		dir := filepath.Join(b.boardDir(boardId), id)
+		if !exists(filepath.Join(dir, listFile)) {
+			continue
+		}
+		list, err := b.readList(dir)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+// Label returns the label called name from the palette of the board.
+func (b *Board) Label(name string) (Label, bool) {
+	for _, l := range b.Labels {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Label{}, false
+}
+
+// HasLabel reports whether the card is labelled name.
+func (c *Card) HasLabel(name string) bool {
+	for _, l := range c.Labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddLabel adds label to the palette of the board. Label names are unique
+// within a board.
+func (b *Board) AddLabel(ctx context.Context, label Label) error {
+	if label.Name == "" {
+		return fmt.Errorf("label name is empty: %w", ErrInvalid)
+	}
+	if _, ok := b.Label(label.Name); ok {
+		return fmt.Errorf("label %q: %w", label.Name, ErrConflict)
+	}
+	labels := b.Labels
+	b.Labels = append(append([]Label(nil), labels...), label)
+	if err := b.backend.UpdateBoard(ctx, b); err != nil {
+		b.Labels = labels
+		return err
+	}
+	return nil
+}
+
+// UpdateLabel replaces the palette label called name with label, and
+// relabels the cards of the board that carry it.
+func (b *Board) UpdateLabel(ctx context.Context, name string, label Label) error {
+	if label.Name == "" {
+		return fmt.Errorf("label name is empty: %w", ErrInvalid)
+	}
+	if _, ok := b.Label(label.Name); ok && label.Name != name {
+		return fmt.Errorf("label %q: %w", label.Name, ErrConflict)
+	}
+	return b.relabel(ctx, name, &label)
+}
+
+// DeleteLabel removes the label called name from the palette of the board
+// and from its cards.
+func (b *Board) DeleteLabel(ctx context.Context, name string) error {
+	return b.relabel(ctx, name, nil)
+}
+
+// relabel replaces the label called name with label, or drops it when label
+// is nil, on the board and on all of its cards.
+func (b *Board) relabel(ctx context.Context, name string, label *Label) error {
+	if _, ok := b.Label(name); !ok {
+		return fmt.Errorf("label %q: %w", name, ErrNotFound)
+	}
+	labels, rev := b.Labels, b.Rev
+	err := RunTx(ctx, b.backend, func(be Backend) error {
+		b.Labels = replaceLabel(labels, name, label)
+		if err := be.UpdateBoard(ctx, b); err != nil {
+			return err
+		}
+		lists, err := be.ListLists(ctx, b.Id)
+		if err != nil {
+			return err
+		}
+		for _, l := range lists {
+			cards, err := be.ListCards(ctx, l.Id)
+			if err != nil {
+				return err
+			}
+			for _, c := range cards {
+				if !c.HasLabel(name) {
+					continue
+				}
+				c.Labels = replaceLabel(c.Labels, name, label)
+				if err := be.UpdateCard(ctx, c); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Labels, b.Rev = labels, rev
+	}
+	return err
+}
+
+func replaceLabel(labels []Label, name string, label *Label) []Label {
+	out := make([]Label, 0, len(labels))
+	for _, l := range labels {
+		switch {
+		case l.Name != name:
+			out = append(out, l)
+		case label != nil:
+			out = append(out, *label)
+		}
+	}
+	return out
+}
@@ -0,0 +1,373 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+type store struct {
+	boards map[string]backend.Board
+	lists  map[string]backend.List
+	cards  map[string]backend.Card
+}
+
+func (s *store) clone() *store {
+	c := &store{
+		boards: make(map[string]backend.Board, len(s.boards)),
+		lists:  make(map[string]backend.List, len(s.lists)),
+		cards:  make(map[string]backend.Card, len(s.cards)),
+	}
+	for k, v := range s.boards {
+		c.boards[k] = v
+	}
+	for k, v := range s.lists {
+		c.lists[k] = v
+	}
+	for k, v := range s.cards {
+		c.cards[k] = v
+	}
+	return c
+}
+
+// Backend keeps boards, lists and cards in maps guarded by a single lock.
+// It is safe for concurrent use and loses everything when discarded.
+type Backend struct {
+	mu   *sync.RWMutex
+	data *store
+	// inTx is set on the Backend handed to Tx callbacks, which already
+	// hold the write lock.
+	inTx bool
+	// reverts take back the revisions stamped on the caller's records when
+	// the transaction is rolled back.
+	reverts *[]func()
+}
+
+func New() *Backend {
+	return &Backend{
+		mu: new(sync.RWMutex),
+		data: &store{
+			boards: make(map[string]backend.Board),
+			lists:  make(map[string]backend.List),
+			cards:  make(map[string]backend.Card),
+		},
+	}
+}
+
+func (b Backend) read(fn func(*store) error) error {
+	if !b.inTx {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+	}
+	return fn(b.data)
+}
+
+func (b Backend) write(fn func(*store) error) error {
+	if !b.inTx {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	}
+	return fn(b.data)
+}
+
+// Tx runs fn while holding the write lock, restoring the previous contents
+// if fn returns an error.
+func (b Backend) Tx(ctx context.Context, fn func(backend.Backend) error) error {
+	if b.inTx {
+		return fn(b)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	snapshot := b.data.clone()
+	tb := b
+	tb.inTx, tb.reverts = true, new([]func())
+	if err := fn(tb); err != nil {
+		*b.data = *snapshot
+		for i := len(*tb.reverts) - 1; i >= 0; i-- {
+			(*tb.reverts)[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// revise bumps *rev, setting it back if the transaction is rolled back.
+func (b Backend) revise(rev *uint64) {
+	if b.reverts != nil {
+		old := *rev
+		*b.reverts = append(*b.reverts, func() { *rev = old })
+	}
+	*rev++
+}
+
+func errorf(kind, id string, err error) error {
+	return fmt.Errorf("%s %s: %w", kind, id, err)
+}
+
+func stale(kind, id string, rev, latest uint64) error {
+	return errorf(kind, id, fmt.Errorf("%w: revision %d is stale, latest is %d", backend.ErrConflict, rev, latest))
+}
+
+func missingParent(kind, id string) error {
+	return fmt.Errorf("%s %q does not exist: %w", kind, id, backend.ErrInvalid)
+}
+
+func newId(id *string) {
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+}
+
+func copyBoard(b backend.Board) backend.Board {
+	b.Labels = append([]backend.Label(nil), b.Labels...)
+	return b
+}
+
+func copyCard(c backend.Card) backend.Card {
+	c.Labels = append([]backend.Label(nil), c.Labels...)
+	return c
+}
+
+func (b Backend) AddBoard(ctx context.Context, board *backend.Board) error {
+	return b.write(func(s *store) error {
+		newId(&board.Id)
+		if _, ok := s.boards[board.Id]; ok {
+			return errorf("board", board.Id, backend.ErrConflict)
+		}
+		board.Rev = 1
+		s.boards[board.Id] = copyBoard(*board)
+		return nil
+	})
+}
+
+func (b Backend) GetBoard(ctx context.Context, id string) (*backend.Board, error) {
+	board := new(backend.Board)
+	err := b.read(func(s *store) error {
+		stored, ok := s.boards[id]
+		if !ok {
+			return errorf("board", id, backend.ErrNotFound)
+		}
+		*board = copyBoard(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	board.SetBackend(b)
+	return board, nil
+}
+
+func (b Backend) UpdateBoard(ctx context.Context, board *backend.Board) error {
+	return b.write(func(s *store) error {
+		stored, ok := s.boards[board.Id]
+		if !ok {
+			return errorf("board", board.Id, backend.ErrNotFound)
+		}
+		if stored.Rev != board.Rev {
+			return stale("board", board.Id, board.Rev, stored.Rev)
+		}
+		b.revise(&board.Rev)
+		s.boards[board.Id] = copyBoard(*board)
+		return nil
+	})
+}
+
+func (b Backend) DeleteBoard(ctx context.Context, id string) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.boards[id]; !ok {
+			return errorf("board", id, backend.ErrNotFound)
+		}
+		for listId, list := range s.lists {
+			if list.BoardId == id {
+				s.deleteList(listId)
+			}
+		}
+		delete(s.boards, id)
+		return nil
+	})
+}
+
+func (b Backend) ListBoards(ctx context.Context) ([]*backend.Board, error) {
+	boards := make([]*backend.Board, 0)
+	err := b.read(func(s *store) error {
+		for _, stored := range s.boards {
+			board := copyBoard(stored)
+			board.SetBackend(b)
+			boards = append(boards, &board)
+		}
+		return nil
+	})
+	sort.Slice(boards, func(i, j int) bool {
+		return boards[i].Id < boards[j].Id
+	})
+	return boards, err
+}
+
+func (b Backend) AddList(ctx context.Context, list *backend.List) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.boards[list.BoardId]; !ok {
+			return missingParent("board", list.BoardId)
+		}
+		newId(&list.Id)
+		if _, ok := s.lists[list.Id]; ok {
+			return errorf("list", list.Id, backend.ErrConflict)
+		}
+		list.Rev = 1
+		s.lists[list.Id] = *list
+		return nil
+	})
+}
+
+func (b Backend) GetList(ctx context.Context, id string) (*backend.List, error) {
+	list := new(backend.List)
+	err := b.read(func(s *store) error {
+		stored, ok := s.lists[id]
+		if !ok {
+			return errorf("list", id, backend.ErrNotFound)
+		}
+		*list = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	list.SetBackend(b)
+	return list, nil
+}
+
+func (b Backend) UpdateList(ctx context.Context, list *backend.List) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.boards[list.BoardId]; !ok {
+			return missingParent("board", list.BoardId)
+		}
+		stored, ok := s.lists[list.Id]
+		if !ok {
+			return errorf("list", list.Id, backend.ErrNotFound)
+		}
+		if stored.Rev != list.Rev {
+			return stale("list", list.Id, list.Rev, stored.Rev)
+		}
+		b.revise(&list.Rev)
+		s.lists[list.Id] = *list
+		return nil
+	})
+}
+
+func (b Backend) DeleteList(ctx context.Context, id string) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.lists[id]; !ok {
+			return errorf("list", id, backend.ErrNotFound)
+		}
+		s.deleteList(id)
+		return nil
+	})
+}
+
+func (s *store) deleteList(id string) {
+	for cardId, card := range s.cards {
+		if card.ListId == id {
+			delete(s.cards, cardId)
+		}
+	}
+	delete(s.lists, id)
+}
+
+func (b Backend) ListLists(ctx context.Context, boardId string) ([]*backend.List, error) {
+	lists := make([]*backend.List, 0)
+	err := b.read(func(s *store) error {
+		for _, stored := range s.lists {
+			if stored.BoardId != boardId {
+				continue
+			}
+			list := stored
+			list.SetBackend(b)
+			lists = append(lists, &list)
+		}
+		return nil
+	})
+	return lists, err
+}
+
+func (b Backend) AddCard(ctx context.Context, card *backend.Card) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.lists[card.ListId]; !ok {
+			return missingParent("list", card.ListId)
+		}
+		newId(&card.Id)
+		if _, ok := s.cards[card.Id]; ok {
+			return errorf("card", card.Id, backend.ErrConflict)
+		}
+		card.Rev = 1
+		card.LastUpdate = time.Now()
+		s.cards[card.Id] = copyCard(*card)
+		return nil
+	})
+}
+
+func (b Backend) GetCard(ctx context.Context, id string) (*backend.Card, error) {
+	card := new(backend.Card)
+	err := b.read(func(s *store) error {
+		stored, ok := s.cards[id]
+		if !ok {
+			return errorf("card", id, backend.ErrNotFound)
+		}
+		*card = copyCard(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	card.SetBackend(b)
+	return card, nil
+}
+
+func (b Backend) UpdateCard(ctx context.Context, card *backend.Card) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.lists[card.ListId]; !ok {
+			return missingParent("list", card.ListId)
+		}
+		stored, ok := s.cards[card.Id]
+		if !ok {
+			return errorf("card", card.Id, backend.ErrNotFound)
+		}
+		if stored.Rev != card.Rev {
+			return stale("card", card.Id, card.Rev, stored.Rev)
+		}
+		b.revise(&card.Rev)
+		card.LastUpdate = time.Now()
+		s.cards[card.Id] = copyCard(*card)
+		return nil
+	})
+}
+
+func (b Backend) DeleteCard(ctx context.Context, id string) error {
+	return b.write(func(s *store) error {
+		if _, ok := s.cards[id]; !ok {
+			return errorf("card", id, backend.ErrNotFound)
+		}
+		delete(s.cards, id)
+		return nil
+	})
+}
+
+func (b Backend) ListCards(ctx context.Context, listId string) ([]*backend.Card, error) {
+	cards := make([]*backend.Card, 0)
+	err := b.read(func(s *store) error {
+		for _, stored := range s.cards {
+			if stored.ListId != listId {
+				continue
+			}
+			card := copyCard(stored)
+			card.SetBackend(b)
+			cards = append(cards, &card)
+		}
+		return nil
+	})
+	return cards, err
+}
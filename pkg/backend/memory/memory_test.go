@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func Test_Backend(t *testing.T) {
+	testutil.TestBackend(t, New())
+}
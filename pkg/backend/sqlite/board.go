@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func (b Backend) AddBoard(ctx context.Context, board *backend.Board) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		if board.Id == "" {
+			board.Id = uuid.NewString()
+		}
+		if err := tb.checkNew(ctx, "boards", "board", board.Id); err != nil {
+			return err
+		}
+		board.Rev = 1
+		_, err := tb.q.ExecContext(ctx, "INSERT INTO boards (id, name, rev) VALUES (?, ?, ?)",
+			board.Id, board.Name, board.Rev)
+		if err != nil {
+			return err
+		}
+		return tb.setBoardLabels(ctx, board)
+	})
+}
+
+func (b Backend) setBoardLabels(ctx context.Context, board *backend.Board) error {
+	if _, err := b.q.ExecContext(ctx, "DELETE FROM board_labels WHERE board_id = ?", board.Id); err != nil {
+		return err
+	}
+	for i, label := range board.Labels {
+		_, err := b.q.ExecContext(ctx, "INSERT INTO board_labels (board_id, idx, name, color) VALUES (?, ?, ?, ?)",
+			board.Id, i, label.Name, label.Color)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBoardLabels fills in the label palettes of boards, which are matched
+// by id.
+func (b Backend) loadBoardLabels(ctx context.Context, boards []*backend.Board, where string, args ...interface{}) error {
+	byId := make(map[string]*backend.Board, len(boards))
+	for _, board := range boards {
+		byId[board.Id] = board
+	}
+	rows, err := b.q.QueryContext(ctx, "SELECT board_id, name, color FROM board_labels"+where+" ORDER BY board_id, idx", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var label backend.Label
+		if err := rows.Scan(&id, &label.Name, &label.Color); err != nil {
+			return err
+		}
+		if board, ok := byId[id]; ok {
+			board.Labels = append(board.Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+func (b Backend) GetBoard(ctx context.Context, id string) (*backend.Board, error) {
+	board := &backend.Board{Id: id}
+	err := b.q.QueryRowContext(ctx, "SELECT name, rev FROM boards WHERE id = ?", id).
+		Scan(&board.Name, &board.Rev)
+	if err == sql.ErrNoRows {
+		return nil, errorf("board", id, backend.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := b.loadBoardLabels(ctx, []*backend.Board{board}, " WHERE board_id = ?", id); err != nil {
+		return nil, err
+	}
+	board.SetBackend(b)
+	return board, nil
+}
+
+func (b Backend) UpdateBoard(ctx context.Context, board *backend.Board) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		res, err := tb.q.ExecContext(ctx, "UPDATE boards SET name = ?, rev = rev + 1 WHERE id = ? AND rev = ?",
+			board.Name, board.Id, board.Rev)
+		if err != nil {
+			return err
+		}
+		if err := tb.revised(ctx, res, "boards", "board", board.Id, board.Rev); err != nil {
+			return err
+		}
+		if err := tb.setBoardLabels(ctx, board); err != nil {
+			return err
+		}
+		tb.revise(&board.Rev)
+		return nil
+	})
+}
+
+func (b Backend) DeleteBoard(ctx context.Context, id string) error {
+	res, err := b.q.ExecContext(ctx, "DELETE FROM boards WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return b.deleted(res, "board", id)
+}
+
+func (b Backend) ListBoards(ctx context.Context) ([]*backend.Board, error) {
+	rows, err := b.q.QueryContext(ctx, "SELECT id, name, rev FROM boards ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	boards := make([]*backend.Board, 0)
+	for rows.Next() {
+		board := new(backend.Board)
+		if err := rows.Scan(&board.Id, &board.Name, &board.Rev); err != nil {
+			return nil, err
+		}
+		board.SetBackend(b)
+		boards = append(boards, board)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := b.loadBoardLabels(ctx, boards, ""); err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
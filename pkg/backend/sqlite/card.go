@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+const cardColumns = "id, list_id, name, description, value, effort, work, pos, rev, last_update, moved_at"
+
+// Times are stored as Unix nanoseconds, with 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+func scanCard(s scanner) (*backend.Card, error) {
+	card := new(backend.Card)
+	var lastUpdate, movedAt int64
+	err := s.Scan(&card.Id, &card.ListId, &card.Name, &card.Description,
+		&card.Value, &card.Effort, &card.Work, &card.Pos, &card.Rev, &lastUpdate, &movedAt)
+	if err != nil {
+		return nil, err
+	}
+	card.LastUpdate = fromUnixNano(lastUpdate)
+	card.MovedAt = fromUnixNano(movedAt)
+	return card, nil
+}
+
+func (b Backend) setLabels(ctx context.Context, card *backend.Card) error {
+	if _, err := b.q.ExecContext(ctx, "DELETE FROM labels WHERE card_id = ?", card.Id); err != nil {
+		return err
+	}
+	for i, label := range card.Labels {
+		_, err := b.q.ExecContext(ctx, "INSERT INTO labels (card_id, idx, name, color) VALUES (?, ?, ?, ?)",
+			card.Id, i, label.Name, label.Color)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadLabels fills in the labels of cards, which are matched by id.
+func (b Backend) loadLabels(ctx context.Context, where string, arg interface{}, cards ...*backend.Card) error {
+	byId := make(map[string]*backend.Card, len(cards))
+	for _, card := range cards {
+		byId[card.Id] = card
+	}
+	rows, err := b.q.QueryContext(ctx, "SELECT card_id, name, color FROM labels WHERE "+where+" ORDER BY card_id, idx", arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var label backend.Label
+		if err := rows.Scan(&id, &label.Name, &label.Color); err != nil {
+			return err
+		}
+		if card, ok := byId[id]; ok {
+			card.Labels = append(card.Labels, label)
+		}
+	}
+	return rows.Err()
+}
+
+func (b Backend) AddCard(ctx context.Context, card *backend.Card) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		if err := tb.checkParent(ctx, "lists", "list", card.ListId); err != nil {
+			return err
+		}
+		if card.Id == "" {
+			card.Id = uuid.NewString()
+		}
+		if err := tb.checkNew(ctx, "cards", "card", card.Id); err != nil {
+			return err
+		}
+		card.Rev = 1
+		card.LastUpdate = time.Now()
+		_, err := tb.q.ExecContext(ctx, "INSERT INTO cards ("+cardColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			card.Id, card.ListId, card.Name, card.Description, card.Value, card.Effort,
+			card.Work, card.Pos, card.Rev, unixNano(card.LastUpdate), unixNano(card.MovedAt))
+		if err != nil {
+			return err
+		}
+		return tb.setLabels(ctx, card)
+	})
+}
+
+func (b Backend) GetCard(ctx context.Context, id string) (*backend.Card, error) {
+	card, err := scanCard(b.q.QueryRowContext(ctx, "SELECT "+cardColumns+" FROM cards WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, errorf("card", id, backend.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := b.loadLabels(ctx, "card_id = ?", id, card); err != nil {
+		return nil, err
+	}
+	card.SetBackend(b)
+	return card, nil
+}
+
+func (b Backend) UpdateCard(ctx context.Context, card *backend.Card) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		if err := tb.checkParent(ctx, "lists", "list", card.ListId); err != nil {
+			return err
+		}
+		now := time.Now()
+		res, err := tb.q.ExecContext(ctx, `UPDATE cards SET list_id = ?, name = ?, description = ?,
+			value = ?, effort = ?, work = ?, pos = ?, rev = rev + 1, last_update = ?, moved_at = ?
+			WHERE id = ? AND rev = ?`,
+			card.ListId, card.Name, card.Description, card.Value, card.Effort,
+			card.Work, card.Pos, unixNano(now), unixNano(card.MovedAt), card.Id, card.Rev)
+		if err != nil {
+			return err
+		}
+		if err := tb.revised(ctx, res, "cards", "card", card.Id, card.Rev); err != nil {
+			return err
+		}
+		if err := tb.setLabels(ctx, card); err != nil {
+			return err
+		}
+		tb.revise(&card.Rev)
+		card.LastUpdate = now
+		return nil
+	})
+}
+
+func (b Backend) DeleteCard(ctx context.Context, id string) error {
+	res, err := b.q.ExecContext(ctx, "DELETE FROM cards WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return b.deleted(res, "card", id)
+}
+
+func (b Backend) ListCards(ctx context.Context, listId string) ([]*backend.Card, error) {
+	rows, err := b.q.QueryContext(ctx, "SELECT "+cardColumns+" FROM cards WHERE list_id = ?", listId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cards := make([]*backend.Card, 0)
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		card.SetBackend(b)
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	where := "card_id IN (SELECT id FROM cards WHERE list_id = ?)"
+	if err := b.loadLabels(ctx, where, listId, cards...); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func (b Backend) AddList(ctx context.Context, list *backend.List) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		if err := tb.checkParent(ctx, "boards", "board", list.BoardId); err != nil {
+			return err
+		}
+		if list.Id == "" {
+			list.Id = uuid.NewString()
+		}
+		if err := tb.checkNew(ctx, "lists", "list", list.Id); err != nil {
+			return err
+		}
+		list.Rev = 1
+		_, err := tb.q.ExecContext(ctx, "INSERT INTO lists (id, board_id, name, pos, rev, ordering) VALUES (?, ?, ?, ?, ?, ?)",
+			list.Id, list.BoardId, list.Name, list.Pos, list.Rev, list.Order)
+		return err
+	})
+}
+
+func (b Backend) GetList(ctx context.Context, id string) (*backend.List, error) {
+	list := &backend.List{Id: id}
+	err := b.q.QueryRowContext(ctx, "SELECT board_id, name, pos, rev, ordering FROM lists WHERE id = ?", id).
+		Scan(&list.BoardId, &list.Name, &list.Pos, &list.Rev, &list.Order)
+	if err == sql.ErrNoRows {
+		return nil, errorf("list", id, backend.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	list.SetBackend(b)
+	return list, nil
+}
+
+func (b Backend) UpdateList(ctx context.Context, list *backend.List) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		if err := tb.checkParent(ctx, "boards", "board", list.BoardId); err != nil {
+			return err
+		}
+		res, err := tb.q.ExecContext(ctx, "UPDATE lists SET board_id = ?, name = ?, pos = ?, ordering = ?, rev = rev + 1 WHERE id = ? AND rev = ?",
+			list.BoardId, list.Name, list.Pos, list.Order, list.Id, list.Rev)
+		if err != nil {
+			return err
+		}
+		if err := tb.revised(ctx, res, "lists", "list", list.Id, list.Rev); err != nil {
+			return err
+		}
+		tb.revise(&list.Rev)
+		return nil
+	})
+}
+
+func (b Backend) DeleteList(ctx context.Context, id string) error {
+	res, err := b.q.ExecContext(ctx, "DELETE FROM lists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return b.deleted(res, "list", id)
+}
+
+func (b Backend) ListLists(ctx context.Context, boardId string) ([]*backend.List, error) {
+	rows, err := b.q.QueryContext(ctx, "SELECT id, name, pos, rev, ordering FROM lists WHERE board_id = ?", boardId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lists := make([]*backend.List, 0)
+	for rows.Next() {
+		list := &backend.List{BoardId: boardId}
+		if err := rows.Scan(&list.Id, &list.Name, &list.Pos, &list.Rev, &list.Order); err != nil {
+			return nil, err
+		}
+		list.SetBackend(b)
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
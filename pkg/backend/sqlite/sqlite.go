@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// migrations are applied in order on open. The index of the last applied
+// migration plus one is kept in PRAGMA user_version, so entries must never
+// be edited or reordered once released; append new ones instead.
+var migrations = []string{
+	`CREATE TABLE boards (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		rev  INTEGER NOT NULL
+	);
+	CREATE TABLE lists (
+		id       TEXT PRIMARY KEY,
+		board_id TEXT NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+		name     TEXT NOT NULL,
+		pos      REAL NOT NULL,
+		rev      INTEGER NOT NULL
+	);
+	CREATE INDEX lists_board_id ON lists(board_id);
+	CREATE TABLE cards (
+		id          TEXT PRIMARY KEY,
+		list_id     TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+		name        TEXT NOT NULL,
+		description TEXT NOT NULL,
+		value       INTEGER NOT NULL,
+		effort      INTEGER NOT NULL,
+		work        INTEGER NOT NULL,
+		pos         REAL NOT NULL,
+		rev         INTEGER NOT NULL,
+		last_update INTEGER NOT NULL
+	);
+	CREATE INDEX cards_list_id ON cards(list_id);
+	CREATE TABLE labels (
+		card_id TEXT NOT NULL REFERENCES cards(id) ON DELETE CASCADE,
+		idx     INTEGER NOT NULL,
+		name    TEXT NOT NULL,
+		color   TEXT NOT NULL,
+		PRIMARY KEY (card_id, idx)
+	);`,
+	`ALTER TABLE cards ADD COLUMN moved_at INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE lists ADD COLUMN ordering TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE board_labels (
+		board_id TEXT NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+		idx      INTEGER NOT NULL,
+		name     TEXT NOT NULL,
+		color    TEXT NOT NULL,
+		PRIMARY KEY (board_id, idx)
+	);`,
+}
+
+type querier interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Backend stores boards, lists, cards and labels in relational tables.
+type Backend struct {
+	db *sql.DB
+	q  querier
+	// tx is set on the Backend handed to Tx callbacks.
+	tx *sql.Tx
+	// reverts take back the revisions stamped on the caller's records when
+	// tx is rolled back.
+	reverts *[]func()
+}
+
+func New(path string) (*Backend, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, err
+	}
+	if path == ":memory:" {
+		// Every connection to :memory: is a separate database.
+		db.SetMaxOpenConns(1)
+	}
+	return NewWithDB(db)
+}
+
+// NewWithDB migrates db to the latest schema. Foreign keys must be enabled
+// on every connection of db for deletes to cascade.
+func NewWithDB(db *sql.DB) (*Backend, error) {
+	b := &Backend{db: db, q: db}
+	if err := b.migrate(context.Background()); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b Backend) migrate(ctx context.Context) error {
+	return b.Tx(ctx, func(be backend.Backend) error {
+		tb := be.(Backend)
+		var version int
+		if err := tb.q.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+			return err
+		}
+		for i := version; i < len(migrations); i++ {
+			if _, err := tb.q.ExecContext(ctx, migrations[i]); err != nil {
+				return fmt.Errorf("migration %d: %w", i+1, err)
+			}
+		}
+		_, err := tb.q.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(migrations)))
+		return err
+	})
+}
+
+// Tx runs fn inside a single SQL transaction, which is committed when fn
+// returns nil and rolled back otherwise. Calls nested in an existing
+// transaction join it.
+func (b Backend) Tx(ctx context.Context, fn func(backend.Backend) error) error {
+	if b.tx != nil {
+		return fn(b)
+	}
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	tb := Backend{db: b.db, q: tx, tx: tx, reverts: new([]func())}
+	err = fn(tb)
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		for i := len(*tb.reverts) - 1; i >= 0; i-- {
+			(*tb.reverts)[i]()
+		}
+	}
+	return err
+}
+
+// revise bumps *rev, setting it back if the transaction is rolled back.
+func (b Backend) revise(rev *uint64) {
+	old := *rev
+	*b.reverts = append(*b.reverts, func() { *rev = old })
+	*rev++
+}
+
+func errorf(kind, id string, err error) error {
+	return fmt.Errorf("%s %s: %w", kind, id, err)
+}
+
+func (b Backend) exists(ctx context.Context, table, id string) (bool, error) {
+	var n int
+	err := b.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE id = ?", id).Scan(&n)
+	return n > 0, err
+}
+
+func (b Backend) checkParent(ctx context.Context, table, kind, id string) error {
+	ok, err := b.exists(ctx, table, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s %q does not exist: %w", kind, id, backend.ErrInvalid)
+	}
+	return nil
+}
+
+func (b Backend) checkNew(ctx context.Context, table, kind, id string) error {
+	ok, err := b.exists(ctx, table, id)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return errorf(kind, id, backend.ErrConflict)
+	}
+	return nil
+}
+
+// revised interprets the result of an UPDATE guarded by "rev = ?": no rows
+// affected means the record is either gone or was written by someone else.
+func (b Backend) revised(ctx context.Context, res sql.Result, table, kind, id string, rev uint64) error {
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+	var latest uint64
+	err = b.q.QueryRowContext(ctx, "SELECT rev FROM "+table+" WHERE id = ?", id).Scan(&latest)
+	if err == sql.ErrNoRows {
+		return errorf(kind, id, backend.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	return errorf(kind, id, fmt.Errorf("%w: revision %d is stale, latest is %d", backend.ErrConflict, rev, latest))
+}
+
+func (b Backend) deleted(res sql.Result, kind, id string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errorf(kind, id, backend.ErrNotFound)
+	}
+	return nil
+}
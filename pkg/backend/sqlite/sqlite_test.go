@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/testutil"
+)
+
+func Test_Backend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite_backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "test.sqlite")
+	defer os.RemoveAll(dir)
+	b, err := New(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	testutil.TestBackend(t, b)
+
+	// Reopening an existing database must not reapply migrations.
+	reopened, err := New(f)
+	testutil.Ok(t, "reopen", err)
+	reopened.Close()
+}
+
+func Test_Labels(t *testing.T) {
+	b, err := New(":memory:")
+	testutil.Ok(t, "open", err)
+	defer b.Close()
+	ctx := context.TODO()
+	board := &backend.Board{Name: "labels"}
+	testutil.Ok(t, "add board", b.AddBoard(ctx, board))
+	list := &backend.List{Name: "labels", BoardId: board.Id}
+	testutil.Ok(t, "add list", b.AddList(ctx, list))
+	labels := []backend.Label{{Color: "red", Name: "bug"}, {Color: "blue", Name: "ui"}}
+	card := &backend.Card{Name: "labels", ListId: list.Id, Labels: labels}
+	testutil.Ok(t, "add card", b.AddCard(ctx, card))
+	cards, err := b.ListCards(ctx, list.Id)
+	testutil.Ok(t, "list cards", err)
+	if len(cards) != 1 || !reflect.DeepEqual(cards[0].Labels, labels) {
+		t.Fatalf("want labels: %v, got: %v", labels, cards)
+	}
+	card.Labels = labels[1:]
+	testutil.Ok(t, "update card", b.UpdateCard(ctx, card))
+	got, err := b.GetCard(ctx, card.Id)
+	testutil.Ok(t, "get card", err)
+	if !reflect.DeepEqual(got.Labels, labels[1:]) {
+		t.Fatalf("want labels: %v, got: %v", labels[1:], got.Labels)
+	}
+}
@@ -0,0 +1,85 @@
+// Package frontmatter reads and writes Markdown documents that start with a
+// YAML front-matter block delimited by "---" lines.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const delimiter = "---"
+
+var ErrMissing = errors.New("missing front-matter")
+
+// Marshal renders meta as front-matter followed by body and the newline
+// ending the file, which Unmarshal takes off again.
+func Marshal(meta interface{}, body string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(meta); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString(delimiter + "\n")
+	if body != "" {
+		buf.WriteString(body + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the front-matter of data into meta and returns the
+// remaining body without the newline ending the file.
+func Unmarshal(data []byte, meta interface{}) (string, error) {
+	head, body, err := split(data)
+	if err != nil {
+		return "", err
+	}
+	if err := yaml.Unmarshal([]byte(head), meta); err != nil {
+		return "", err
+	}
+	return body, nil
+}
+
+// UnmarshalStrict is like Unmarshal but fails on front-matter keys that
+// have no field in meta, for documents written by hand.
+func UnmarshalStrict(data []byte, meta interface{}) (string, error) {
+	head, body, err := split(data)
+	if err != nil {
+		return "", err
+	}
+	dec := yaml.NewDecoder(strings.NewReader(head))
+	dec.KnownFields(true)
+	if err := dec.Decode(meta); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return body, nil
+}
+
+func split(data []byte) (string, string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, delimiter+"\n") {
+		return "", "", ErrMissing
+	}
+	text = text[len(delimiter)+1:]
+	var head, body string
+	switch end := strings.Index(text, "\n"+delimiter+"\n"); {
+	case strings.HasPrefix(text, delimiter+"\n"):
+		body = text[len(delimiter)+1:]
+	case end >= 0:
+		head, body = text[:end+1], text[end+len(delimiter)+2:]
+	case strings.HasSuffix(text, "\n"+delimiter):
+		head = text[:len(text)-len(delimiter)]
+	default:
+		return "", "", fmt.Errorf("%w: unterminated block", ErrMissing)
+	}
+	return head, strings.TrimSuffix(body, "\n"), nil
+}
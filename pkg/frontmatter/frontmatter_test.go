@@ -0,0 +1,82 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type meta struct {
+	Name   string   `yaml:"Name"`
+	Value  int      `yaml:"Value"`
+	Labels []string `yaml:"Labels,omitempty"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := map[string]struct {
+		meta meta
+		body string
+	}{
+		"empty body": {meta{Name: "a", Value: 1}, ""},
+		"markdown":   {meta{Name: "b", Labels: []string{"x", "y"}}, "# Title\n\n- one\n---\nafter rule"},
+		"newlines":   {meta{Name: "c"}, "code\n\n"},
+		"newline":    {meta{Name: "d"}, "\n"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b, err := Marshal(tc.meta, tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got meta
+			body, err := Unmarshal(b, &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.meta) || body != tc.body {
+				t.Fatalf("want: %v %q, got: %v %q", tc.meta, tc.body, got, body)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		want  meta
+		body  string
+		err   error
+	}{
+		"crlf":         {"---\r\nName: a\r\n---\r\nbody\r\n", meta{Name: "a"}, "body", nil},
+		"no body":      {"---\nName: a\n---", meta{Name: "a"}, "", nil},
+		"empty":        {"---\n---\nbody", meta{}, "body", nil},
+		"missing":      {"Name: a\n", meta{}, "", ErrMissing},
+		"unterminated": {"---\nName: a\n", meta{}, "", ErrMissing},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got meta
+			body, err := Unmarshal([]byte(tc.input), &got)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("want error: %v, got: %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) || body != tc.body {
+				t.Fatalf("want: %v %q, got: %v %q", tc.want, tc.body, got, body)
+			}
+		})
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	var got meta
+	body, err := UnmarshalStrict([]byte("---\nName: a\n---\nbody\n"), &got)
+	if err != nil || got.Name != "a" || body != "body" {
+		t.Fatalf("want a and body, got: %v %q %v", got, body, err)
+	}
+	if _, err := UnmarshalStrict([]byte("---\nName: a\nVaule: 1\n---\n"), &got); err == nil {
+		t.Fatal("want an error for an unknown key")
+	}
+	if _, err := UnmarshalStrict([]byte("---\n---\n"), &got); err != nil {
+		t.Fatalf("empty front-matter should decode, got: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package query parses card filters such as
+//
+//	crash label:bug value>5 effort<=3 list:DOING
+//
+// Bare words must all appear in the name or description of a card, and
+// field terms narrow the match further. Text is compared case-insensitively
+// and double quotes keep spaces inside a word or field value. Words with an
+// unknown field, such as http://example.com, and words starting with a
+// quote are plain text.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+var ErrSyntax = errors.New("invalid query")
+
+// Query is a parsed filter. The zero Query matches every card.
+type Query struct {
+	text  []string
+	terms []term
+}
+
+type term struct {
+	field string
+	op    string
+	value string
+	num   int
+}
+
+var numericFields = map[string]func(*backend.Card) int{
+	"value":  func(c *backend.Card) int { return c.Value },
+	"effort": func(c *backend.Card) int { return c.Effort },
+	"work":   func(c *backend.Card) int { return c.Work },
+}
+
+// ops are tried in order, so longer operators come first.
+var ops = []string{">=", "<=", "!=", ">", "<", "=", ":"}
+
+// Parse parses s, failing with ErrSyntax on unterminated quotes, operators
+// that do not apply to a field and malformed numbers.
+func Parse(s string) (Query, error) {
+	var q Query
+	words, err := split(s)
+	if err != nil {
+		return q, err
+	}
+	for _, w := range words {
+		t, ok, err := parseTerm(w)
+		if err != nil {
+			return Query{}, err
+		}
+		if ok {
+			q.terms = append(q.terms, t)
+		} else {
+			q.text = append(q.text, strings.ToLower(w.text))
+		}
+	}
+	return q, nil
+}
+
+type word struct {
+	text string
+	// quoted is set when the word starts with a quote.
+	quoted bool
+}
+
+// split breaks s into words on spaces outside double quotes, dropping the
+// quotes.
+func split(s string) ([]word, error) {
+	var words []word
+	var w word
+	var text strings.Builder
+	quoted, inWord := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			if !inWord {
+				w.quoted = true
+			}
+			quoted, inWord = !quoted, true
+		case r == ' ' && !quoted:
+			if inWord {
+				w.text = text.String()
+				words = append(words, w)
+				w = word{}
+				text.Reset()
+				inWord = false
+			}
+		default:
+			text.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrSyntax)
+	}
+	if inWord {
+		w.text = text.String()
+		words = append(words, w)
+	}
+	return words, nil
+}
+
+// parseTerm parses a field term, reporting false for a bare word.
+func parseTerm(w word) (term, bool, error) {
+	if w.quoted {
+		return term{}, false, nil
+	}
+	for i, r := range w.text {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			if i == 0 {
+				return term{}, false, nil
+			}
+			return parseField(strings.ToLower(w.text[:i]), w.text[i:])
+		}
+	}
+	return term{}, false, nil
+}
+
+func parseField(field, rest string) (term, bool, error) {
+	op := ""
+	for _, o := range ops {
+		if strings.HasPrefix(rest, o) {
+			op = o
+			break
+		}
+	}
+	if op == "" || field != "label" && field != "list" && numericFields[field] == nil {
+		return term{}, false, nil
+	}
+	t := term{field: field, op: op, value: rest[len(op):]}
+	if t.op == "=" {
+		t.op = ":"
+	}
+	switch {
+	case field == "label" || field == "list":
+		if t.op != ":" && t.op != "!=" {
+			return term{}, false, fmt.Errorf("%w: %s%s needs : or !=", ErrSyntax, field, op)
+		}
+		t.value = strings.ToLower(t.value)
+	case numericFields[field] != nil:
+		n, err := strconv.Atoi(t.value)
+		if err != nil {
+			return term{}, false, fmt.Errorf("%w: %s%s%s is not a number", ErrSyntax, field, op, t.value)
+		}
+		t.num = n
+	}
+	return t, true, nil
+}
+
+// Empty reports whether the query matches every card.
+func (q Query) Empty() bool {
+	return len(q.text) == 0 && len(q.terms) == 0
+}
+
+// Match reports whether card, which is in list, matches every part of the
+// query. list may be nil when unknown, in which case list terms fail.
+func (q Query) Match(card *backend.Card, list *backend.List) bool {
+	text := strings.ToLower(card.Name + "\n" + card.Description)
+	for _, w := range q.text {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	for _, t := range q.terms {
+		if !t.match(card, list) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(card *backend.Card, list *backend.List) bool {
+	switch t.field {
+	case "label":
+		found := false
+		for _, l := range card.Labels {
+			if strings.ToLower(l.Name) == t.value {
+				found = true
+			}
+		}
+		return found == (t.op == ":")
+	case "list":
+		if list == nil {
+			return false
+		}
+		return (strings.ToLower(list.Name) == t.value) == (t.op == ":")
+	}
+	n := numericFields[t.field](card)
+	switch t.op {
+	case ">=":
+		return n >= t.num
+	case "<=":
+		return n <= t.num
+	case "!=":
+		return n != t.num
+	case ">":
+		return n > t.num
+	case "<":
+		return n < t.num
+	}
+	return n == t.num
+}
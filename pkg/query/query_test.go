@@ -0,0 +1,69 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func TestMatch(t *testing.T) {
+	doing := &backend.List{Name: "DOING"}
+	card := &backend.Card{
+		Name:        "Fix login crash",
+		Description: "Happens on the second attempt, see http://x/login. note: retry",
+		Value:       8,
+		Effort:      3,
+		Work:        1,
+		Labels:      []backend.Label{{Name: "bug", Color: "red"}},
+	}
+	cases := map[string]bool{
+		"":                             true,
+		"crash":                        true,
+		"CRASH second":                 true,
+		"crash missing":                false,
+		`"login crash"`:                true,
+		`"crash login"`:                false,
+		"label:bug":                    true,
+		"label:BUG":                    true,
+		"label:feature":                false,
+		"label!=bug":                   false,
+		"value>5":                      true,
+		"value>8":                      false,
+		"value>=8 effort<=3":           true,
+		"effort<3":                     false,
+		"work=1":                       true,
+		"work:1 value!=8":              false,
+		"list:doing":                   true,
+		"list:DONE":                    false,
+		`list:"ready to develop"`:      false,
+		"label:bug value>5 list:DOING": true,
+		"http://x/login":               true,
+		"https://x/login":              false,
+		`"note: retry"`:                true,
+		`"note: later"`:                false,
+		"note:":                        true,
+		"size:3":                       false,
+	}
+	for s, want := range cases {
+		q, err := Parse(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if got := q.Match(card, doing); got != want {
+			t.Errorf("%q: want %v, got %v", s, want, got)
+		}
+	}
+	q, _ := Parse("list:doing")
+	if q.Match(card, nil) {
+		t.Fatal("list term should not match without a list")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"value>five", "label>bug", `"open`} {
+		if _, err := Parse(s); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: want ErrSyntax, got %v", s, err)
+		}
+	}
+}
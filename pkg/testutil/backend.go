@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -109,10 +110,12 @@ func TestBackend(t *testing.T, b backend.Backend) {
 	equalStrings(t, card.Name, newCard.Name)
 
 	list.Name = fmt.Sprintf("%s-new", listName)
+	list.Order = backend.ManualOrder
 	Ok(t, "update list", b.UpdateList(ctx, list))
 	newList, err := b.GetList(ctx, listId)
 	Ok(t, "get list", err)
 	equalStrings(t, list.Name, newList.Name)
+	equalStrings(t, string(list.Order), string(newList.Order))
 
 	board.Name = fmt.Sprintf("%s-new", boardName)
 	Ok(t, "update board", b.UpdateBoard(ctx, board))
@@ -125,8 +128,14 @@ func TestBackend(t *testing.T, b backend.Backend) {
 	lists, err = board.Lists(ctx)
 	Ok(t, "list lists", err)
 	list2Id := findId(t, listsToItems(lists), list2.Name)
-	card.ListId = list2Id
-	Ok(t, "update card", card.Update(ctx))
+	list2, err = b.GetList(ctx, list2Id)
+	Ok(t, "get list2", err)
+	Ok(t, "move card", card.Move(ctx, list2))
+	movedCard, err := b.GetCard(ctx, cardId)
+	Ok(t, "get moved card", err)
+	if !movedCard.MovedAt.Equal(card.MovedAt) || movedCard.MovedAt.IsZero() {
+		t.Fatalf("want MovedAt: %v, got: %v", card.MovedAt, movedCard.MovedAt)
+	}
 	cards, err = b.ListCards(ctx, list2Id)
 	Ok(t, "list cards", err)
 	findId(t, cardsToItems(cards), card.Name)
@@ -138,4 +147,236 @@ func TestBackend(t *testing.T, b backend.Backend) {
 	Ok(t, "delete list", b.DeleteList(ctx, listId))
 	Ok(t, "delete list2", b.DeleteList(ctx, list2Id))
 	Ok(t, "delete board", b.DeleteBoard(ctx, boardId))
+
+	testCascadeDelete(t, b)
+	testClone(t, b)
+	testErrors(t, b)
+	testRevisions(t, b)
+	if tb, ok := b.(backend.Transactor); ok {
+		testTransactor(t, b, tb)
+	}
+}
+
+func isErr(t *testing.T, msg string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: want: %v, got: %v", msg, target, err)
+	}
+}
+
+func testErrors(t *testing.T, b backend.Backend) {
+	t.Helper()
+	ctx := context.TODO()
+	missing := "missing"
+	_, err := b.GetBoard(ctx, missing)
+	isErr(t, "get missing board", err, backend.ErrNotFound)
+	_, err = b.GetList(ctx, missing)
+	isErr(t, "get missing list", err, backend.ErrNotFound)
+	_, err = b.GetCard(ctx, missing)
+	isErr(t, "get missing card", err, backend.ErrNotFound)
+	isErr(t, "update missing board", b.UpdateBoard(ctx, &backend.Board{Id: missing}), backend.ErrNotFound)
+	isErr(t, "delete missing card", b.DeleteCard(ctx, missing), backend.ErrNotFound)
+
+	isErr(t, "add list to missing board", b.AddList(ctx, &backend.List{BoardId: missing}), backend.ErrInvalid)
+	isErr(t, "add card to missing list", b.AddCard(ctx, &backend.Card{ListId: missing}), backend.ErrInvalid)
+
+	board := &backend.Board{Name: "errors"}
+	Ok(t, "add board", b.AddBoard(ctx, board))
+	isErr(t, "add duplicate board", b.AddBoard(ctx, &backend.Board{Id: board.Id}), backend.ErrConflict)
+	list := &backend.List{Name: "errors", BoardId: board.Id}
+	Ok(t, "add list", b.AddList(ctx, list))
+	card := &backend.Card{Name: "errors", ListId: list.Id}
+	Ok(t, "add card", b.AddCard(ctx, card))
+	card.ListId = missing
+	isErr(t, "move card to missing list", b.UpdateCard(ctx, card), backend.ErrInvalid)
+	Ok(t, "delete board", b.DeleteBoard(ctx, board.Id))
+}
+
+func testRevisions(t *testing.T, b backend.Backend) {
+	t.Helper()
+	ctx := context.TODO()
+	board := &backend.Board{Name: "revisions"}
+	Ok(t, "add board", b.AddBoard(ctx, board))
+	list := &backend.List{Name: "revisions", BoardId: board.Id}
+	Ok(t, "add list", b.AddList(ctx, list))
+	card := &backend.Card{Name: "revisions", ListId: list.Id}
+	Ok(t, "add card", b.AddCard(ctx, card))
+	if card.LastUpdate.IsZero() {
+		t.Fatal("add card should stamp LastUpdate")
+	}
+
+	mine, err := b.GetCard(ctx, card.Id)
+	Ok(t, "get card", err)
+	theirs, err := b.GetCard(ctx, card.Id)
+	Ok(t, "get card", err)
+	theirs.Name = "theirs"
+	Ok(t, "update card", b.UpdateCard(ctx, theirs))
+	if theirs.Rev <= mine.Rev {
+		t.Fatalf("update should bump revision past %d, got: %d", mine.Rev, theirs.Rev)
+	}
+	mine.Name = "mine"
+	isErr(t, "update stale card", b.UpdateCard(ctx, mine), backend.ErrConflict)
+	mine, err = b.GetCard(ctx, card.Id)
+	Ok(t, "reload card", err)
+	equalStrings(t, theirs.Name, mine.Name)
+	mine.Name = "mine"
+	Ok(t, "update reloaded card", b.UpdateCard(ctx, mine))
+
+	stale := *list
+	list.Name = "renamed"
+	Ok(t, "update list", b.UpdateList(ctx, list))
+	isErr(t, "update stale list", b.UpdateList(ctx, &stale), backend.ErrConflict)
+	staleBoard := *board
+	Ok(t, "update board", b.UpdateBoard(ctx, board))
+	isErr(t, "update stale board", b.UpdateBoard(ctx, &staleBoard), backend.ErrConflict)
+	Ok(t, "delete board", b.DeleteBoard(ctx, board.Id))
+}
+
+func testTransactor(t *testing.T, b backend.Backend, tb backend.Transactor) {
+	t.Helper()
+	ctx := context.TODO()
+	board := &backend.Board{Name: "rollback"}
+	errAbort := errors.New("abort")
+	err := tb.Tx(ctx, func(be backend.Backend) error {
+		if err := be.AddBoard(ctx, board); err != nil {
+			return err
+		}
+		if _, err := be.GetBoard(ctx, board.Id); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("want: %v, got: %v", errAbort, err)
+	}
+	if _, err := b.GetBoard(ctx, board.Id); err == nil {
+		t.Fatalf("board %s should be rolled back", board.Id)
+	}
+
+	Ok(t, "commit", tb.Tx(ctx, func(be backend.Backend) error {
+		return be.AddBoard(ctx, board)
+	}))
+	_, err = b.GetBoard(ctx, board.Id)
+	Ok(t, "get committed board", err)
+
+	// Revisions bumped in a rolled back transaction are taken back, so the
+	// records can still be updated.
+	list := &backend.List{Name: "rollback", BoardId: board.Id}
+	Ok(t, "add list", b.AddList(ctx, list))
+	card := &backend.Card{Name: "rollback", ListId: list.Id}
+	Ok(t, "add card", b.AddCard(ctx, card))
+	err = tb.Tx(ctx, func(be backend.Backend) error {
+		for _, update := range []func() error{
+			func() error { return be.UpdateBoard(ctx, board) },
+			func() error { return be.UpdateList(ctx, list) },
+			func() error { return be.UpdateCard(ctx, card) },
+			func() error { return be.UpdateCard(ctx, card) },
+		} {
+			if err := update(); err != nil {
+				return err
+			}
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("want: %v, got: %v", errAbort, err)
+	}
+	Ok(t, "update board after rollback", b.UpdateBoard(ctx, board))
+	Ok(t, "update list after rollback", b.UpdateList(ctx, list))
+	Ok(t, "update card after rollback", b.UpdateCard(ctx, card))
+	Ok(t, "delete board", b.DeleteBoard(ctx, board.Id))
+}
+
+func testCascadeDelete(t *testing.T, b backend.Backend) {
+	t.Helper()
+	ctx := context.TODO()
+	board := &backend.Board{Name: "cascade"}
+	Ok(t, "add board", b.AddBoard(ctx, board))
+	board.SetBackend(b)
+	keep, drop := &backend.List{Name: "keep"}, &backend.List{Name: "drop"}
+	Ok(t, "add lists", board.AddLists(ctx, keep, drop))
+	keep.SetBackend(b)
+	drop.SetBackend(b)
+	kept, dropped := &backend.Card{Name: "kept"}, &backend.Card{Name: "dropped"}
+	Ok(t, "add card", keep.AddCards(ctx, kept))
+	Ok(t, "add card", drop.AddCards(ctx, dropped))
+
+	Ok(t, "delete list", b.DeleteList(ctx, drop.Id))
+	if _, err := b.GetCard(ctx, dropped.Id); err == nil {
+		t.Fatalf("card %s should be deleted with its list", dropped.Id)
+	}
+	_, err := b.GetCard(ctx, kept.Id)
+	Ok(t, "get card in other list", err)
+
+	rescue, rescued := &backend.List{Name: "rescue"}, &backend.Card{Name: "rescued"}
+	Ok(t, "add list", board.AddLists(ctx, rescue))
+	rescue.SetBackend(b)
+	Ok(t, "add card", rescue.AddCards(ctx, rescued))
+	Ok(t, "delete list moving cards", rescue.DeleteMovingCards(ctx, keep))
+	if _, err := b.GetList(ctx, rescue.Id); err == nil {
+		t.Fatalf("list %s should be deleted", rescue.Id)
+	}
+	moved, err := b.GetCard(ctx, rescued.Id)
+	Ok(t, "get moved card", err)
+	equalStrings(t, keep.Id, moved.ListId)
+
+	Ok(t, "delete board", b.DeleteBoard(ctx, board.Id))
+	if _, err := b.GetList(ctx, keep.Id); err == nil {
+		t.Fatalf("list %s should be deleted with its board", keep.Id)
+	}
+	if _, err := b.GetCard(ctx, kept.Id); err == nil {
+		t.Fatalf("card %s should be deleted with its board", kept.Id)
+	}
+	lists, err := b.ListLists(ctx, board.Id)
+	Ok(t, "list lists", err)
+	if len(lists) != 0 {
+		t.Fatalf("want no lists after board delete, got: %v", listsToItems(lists))
+	}
+}
+
+func testClone(t *testing.T, b backend.Backend) {
+	t.Helper()
+	ctx := context.TODO()
+	board := &backend.Board{Name: "original", Labels: []backend.Label{{Color: "red", Name: "bug"}}}
+	Ok(t, "add board", b.AddBoard(ctx, board))
+	board.SetBackend(b)
+	board.Labels = append(board.Labels, backend.Label{Color: "green", Name: "feature"})
+	Ok(t, "update board labels", board.Update(ctx))
+	stored, err := b.GetBoard(ctx, board.Id)
+	Ok(t, "get board", err)
+	if len(stored.Labels) != 2 || stored.Labels[1].Name != "feature" || stored.Labels[1].Color != "green" {
+		t.Fatalf("want board labels %v, got: %v", board.Labels, stored.Labels)
+	}
+	list := &backend.List{Name: "todo", Order: backend.ManualOrder}
+	Ok(t, "add list", board.AddLists(ctx, list))
+	list.SetBackend(b)
+	card := &backend.Card{Name: "task", Value: 2, Labels: []backend.Label{{Color: "red", Name: "bug"}}}
+	Ok(t, "add card", list.AddCards(ctx, card))
+
+	clone, err := board.Clone(ctx, "copy")
+	Ok(t, "clone board", err)
+	if clone.Id == board.Id {
+		t.Fatalf("clone should get a new id, got %s", clone.Id)
+	}
+	equalStrings(t, "copy", clone.Name)
+	if len(clone.Labels) != 2 {
+		t.Fatalf("clone should keep the label palette, got: %v", clone.Labels)
+	}
+	lists, err := clone.Lists(ctx)
+	Ok(t, "list cloned lists", err)
+	if len(lists) != 1 || lists[0].Id == list.Id || lists[0].Order != backend.ManualOrder {
+		t.Fatalf("want a new manual list, got: %v", listsToItems(lists))
+	}
+	cards, err := lists[0].Cards(ctx)
+	Ok(t, "list cloned cards", err)
+	if len(cards) != 1 || cards[0].Id == card.Id || cards[0].Value != 2 || len(cards[0].Labels) != 1 {
+		t.Fatalf("want a copy of card %s, got: %+v", card.Id, cards)
+	}
+	cards, err = list.Cards(ctx)
+	Ok(t, "list original cards", err)
+	if len(cards) != 1 {
+		t.Fatalf("original list should keep its card, got: %v", cardsToItems(cards))
+	}
+	Ok(t, "delete clone", clone.Delete(ctx))
+	Ok(t, "delete board", board.Delete(ctx))
 }
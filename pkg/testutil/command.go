@@ -0,0 +1,48 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// Command runs cmd with args as the orga binary would and returns what it
+// printed, or its error message if it failed, and its exit code.
+func Command(t *testing.T, cmd *cli.Command, args ...string) (string, int) {
+	t.Helper()
+	var out bytes.Buffer
+	app := &cli.App{
+		Name:           "orga",
+		Commands:       []*cli.Command{cmd},
+		Writer:         &out,
+		ErrWriter:      io.Discard,
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	err := app.Run(append([]string{"orga", cmd.Name}, args...))
+	var exit cli.ExitCoder
+	switch {
+	case err == nil:
+		return out.String(), 0
+	case errors.As(err, &exit):
+		return err.Error(), exit.ExitCode()
+	}
+	t.Fatalf("%s %v: %v", cmd.Name, args, err)
+	return "", 0
+}
+
+// Reset deletes every board of be, for tests sharing a backend.
+func Reset(t *testing.T, be backend.Backend) {
+	t.Helper()
+	ctx := context.Background()
+	boards, err := be.ListBoards(ctx)
+	Ok(t, "list boards", err)
+	for _, b := range boards {
+		Ok(t, "delete board", be.DeleteBoard(ctx, b.Id))
+	}
+}
@@ -0,0 +1,331 @@
+// Package trello converts the JSON export of a Trello board into an
+// archive.Document that can be imported like any exported board.
+package trello
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twistedogic/orga/pkg/archive"
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+type board struct {
+	Id, Name   string
+	Labels     []label
+	Lists      []list
+	Cards      []card
+	Checklists []checklist
+	Actions    []action
+}
+
+type label struct {
+	Id, Name, Color string
+}
+
+type list struct {
+	Id, Name string
+	Closed   bool
+	Pos      float64
+}
+
+type card struct {
+	Id, Name, Desc, IdList string
+	Closed                 bool
+	Pos                    float64
+	Due                    *time.Time
+	DueComplete            bool
+	IdLabels               []string
+	Labels                 []label
+	DateLastActivity       time.Time
+}
+
+type checklist struct {
+	Id, Name, IdCard string
+	Pos              float64
+	CheckItems       []struct {
+		Name, State string
+		Pos         float64
+	}
+}
+
+type action struct {
+	Type string
+	Date time.Time
+	Data struct {
+		Text string
+		Card struct{ Id string }
+	}
+	MemberCreator struct{ FullName, Username string }
+}
+
+// Fields of the export that are mapped, or that only repeat what is mapped
+// or describe Trello itself, and so are never reported as unmapped.
+var known = map[string][]string{
+	"board": {"id", "name", "labels", "lists", "cards", "checklists", "actions",
+		"closed", "url", "shortUrl", "shortLink", "prefs", "limits", "dateLastActivity",
+		"dateLastView", "idOrganization", "idEnterprise", "pinned", "starred", "subscribed",
+		"labelNames", "idBoardSource", "idMemberCreator", "idTags", "enterpriseOwned",
+		"premiumFeatures", "nodeId", "creationMethod", "ixUpdate", "templateGallery",
+		"datePluginDisable", "pluginData", "powerUps", "switcherViews"},
+	"list": {"id", "name", "closed", "pos", "idBoard", "subscribed", "softLimit",
+		"limits", "color", "creationMethod", "idOrganization", "nodeId", "status",
+		"datasource", "type"},
+	"card": {"id", "name", "desc", "idList", "closed", "pos", "due", "dueComplete",
+		"idLabels", "labels", "idChecklists", "dateLastActivity", "idBoard", "idShort",
+		"shortLink", "shortUrl", "url", "badges", "subscribed", "descData", "manualCoverAttachment",
+		"cover", "limits", "isTemplate", "cardRole", "nodeId", "idAttachmentCover", "pinned",
+		"creationMethod", "dueReminder", "email", "checkItemStates", "idMembersVoted"},
+}
+
+// Report lists what could not be carried over from the export.
+type Report struct {
+	// Unmapped counts the records with a non-empty value in each field
+	// that has no counterpart, such as "card.idMembers".
+	Unmapped map[string]int
+	// ClosedLists and ClosedCards count the archived lists and cards,
+	// which are left out.
+	ClosedLists, ClosedCards int
+	// ClosedListCards counts the cards that are not archived themselves but
+	// are left out with their archived or missing list.
+	ClosedListCards int
+}
+
+// Lines describes the report for people, one finding per line.
+func (r *Report) Lines() []string {
+	var lines []string
+	if r.ClosedLists > 0 || r.ClosedCards > 0 {
+		lines = append(lines, fmt.Sprintf("skipped %d archived list(s) and %d archived card(s)", r.ClosedLists, r.ClosedCards))
+	}
+	if r.ClosedListCards > 0 {
+		lines = append(lines, fmt.Sprintf("skipped %d card(s) of archived lists", r.ClosedListCards))
+	}
+	fields := make([]string, 0, len(r.Unmapped))
+	for f := range r.Unmapped {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		lines = append(lines, fmt.Sprintf("unmapped field %s set on %d record(s)", f, r.Unmapped[f]))
+	}
+	return lines
+}
+
+// Parse reads a Trello board export. Lists keep their Trello order and
+// cards their position, so lists are manually ordered. Due dates,
+// checklists and comments are added to the card descriptions.
+func Parse(r io.Reader) (*archive.Document, *Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var b board
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, nil, fmt.Errorf("not a Trello export: %v: %w", err, backend.ErrInvalid)
+	}
+	if b.Name == "" || b.Lists == nil {
+		return nil, nil, fmt.Errorf("not a Trello board export: %w", backend.ErrInvalid)
+	}
+	report := &Report{Unmapped: make(map[string]int)}
+	if err := unmapped(data, report); err != nil {
+		return nil, nil, err
+	}
+
+	doc := &archive.Document{
+		Version: archive.Version,
+		Board:   archive.Board{Id: b.Id, Name: b.Name, Labels: []backend.Label{}},
+	}
+	labels := make(map[string]backend.Label)
+	palette := b.Labels
+	for _, c := range b.Cards {
+		palette = append(palette, c.Labels...)
+	}
+	for _, l := range palette {
+		if _, ok := labels[l.Id]; ok {
+			continue
+		}
+		label := backend.Label{Name: l.Name, Color: color(l.Color)}
+		if label.Name == "" {
+			label.Name = l.Color
+		}
+		if label.Name == "" {
+			continue
+		}
+		labels[l.Id] = label
+		if !hasLabel(doc.Board.Labels, label.Name) {
+			doc.Board.Labels = append(doc.Board.Labels, label)
+		}
+	}
+
+	lists := append([]list(nil), b.Lists...)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+	index := make(map[string]int)
+	for _, l := range lists {
+		if l.Closed {
+			report.ClosedLists++
+			continue
+		}
+		index[l.Id] = len(doc.Board.Lists)
+		doc.Board.Lists = append(doc.Board.Lists, archive.List{
+			Id: l.Id, Name: l.Name, Pos: float64(len(doc.Board.Lists)),
+			Order: backend.ManualOrder, Cards: []archive.Card{},
+		})
+	}
+
+	checklists := make(map[string][]checklist)
+	for _, c := range b.Checklists {
+		checklists[c.IdCard] = append(checklists[c.IdCard], c)
+	}
+	comments := make(map[string][]action)
+	for _, a := range b.Actions {
+		if a.Type == "commentCard" {
+			comments[a.Data.Card.Id] = append(comments[a.Data.Card.Id], a)
+		}
+	}
+	for _, c := range b.Cards {
+		i, ok := index[c.IdList]
+		if c.Closed {
+			report.ClosedCards++
+			continue
+		}
+		if !ok {
+			report.ClosedListCards++
+			continue
+		}
+		out := archive.Card{
+			Id: c.Id, Name: c.Name, Pos: c.Pos,
+			Description: description(c, checklists[c.Id], comments[c.Id]),
+			LastUpdate:  c.DateLastActivity, Labels: []backend.Label{},
+		}
+		for _, id := range c.IdLabels {
+			if l, ok := labels[id]; ok && !hasLabel(out.Labels, l.Name) {
+				out.Labels = append(out.Labels, l)
+			}
+		}
+		doc.Board.Lists[i].Cards = append(doc.Board.Lists[i].Cards, out)
+	}
+	for _, l := range doc.Board.Lists {
+		sort.SliceStable(l.Cards, func(i, j int) bool { return l.Cards[i].Pos < l.Cards[j].Pos })
+	}
+	return doc, report, nil
+}
+
+// description is the Markdown description of c followed by its due date,
+// checklists and comments.
+func description(c card, checklists []checklist, comments []action) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(c.Desc, "\n"))
+	section := func(title string) {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("## " + title + "\n\n")
+	}
+	if c.Due != nil {
+		section("Due")
+		done := ""
+		if c.DueComplete {
+			done = " (done)"
+		}
+		fmt.Fprintf(&b, "%s%s", c.Due.Format("2006-01-02 15:04"), done)
+	}
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+	for _, cl := range checklists {
+		section(cl.Name)
+		items := cl.CheckItems
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+		for i, item := range items {
+			mark := " "
+			if item.State == "complete" {
+				mark = "x"
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "- [%s] %s", mark, item.Name)
+		}
+	}
+	if len(comments) > 0 {
+		section("Comments")
+		// Trello lists actions newest first.
+		sort.SliceStable(comments, func(i, j int) bool { return comments[i].Date.Before(comments[j].Date) })
+		for i, a := range comments {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			who := a.MemberCreator.FullName
+			if who == "" {
+				who = a.MemberCreator.Username
+			}
+			fmt.Fprintf(&b, "**%s** %s\n\n%s", who, a.Date.Format("2006-01-02 15:04"), a.Data.Text)
+		}
+	}
+	return b.String()
+}
+
+// color maps a Trello label colour to the nearest label colour of orga.
+func color(c string) string {
+	c, _, _ = strings.Cut(c, "_")
+	switch c {
+	case "sky":
+		return "blue"
+	case "lime":
+		return "green"
+	case "pink":
+		return "red"
+	case "red", "orange", "yellow", "green", "blue", "purple":
+		return c
+	}
+	return "gray"
+}
+
+func hasLabel(labels []backend.Label, name string) bool {
+	for _, l := range labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unmapped counts the fields of the board, its lists and its cards that
+// are set but not known.
+func unmapped(data []byte, report *Report) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	count("board", raw, report)
+	for _, kind := range []string{"list", "card"} {
+		var records []map[string]json.RawMessage
+		if err := json.Unmarshal(raw[kind+"s"], &records); err != nil && raw[kind+"s"] != nil {
+			return fmt.Errorf("%ss: %v: %w", kind, err, backend.ErrInvalid)
+		}
+		for _, r := range records {
+			count(kind, r, report)
+		}
+	}
+	return nil
+}
+
+func count(kind string, record map[string]json.RawMessage, report *Report) {
+	for field, value := range record {
+		if slices.Contains(known[kind], field) || empty(value) {
+			continue
+		}
+		report.Unmapped[kind+"."+field]++
+	}
+}
+
+func empty(value json.RawMessage) bool {
+	switch strings.TrimSpace(string(value)) {
+	case "", "null", `""`, "[]", "{}", "false", "0":
+		return true
+	}
+	return false
+}
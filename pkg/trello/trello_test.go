@@ -0,0 +1,113 @@
+package trello
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/archive"
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+)
+
+func parse(t *testing.T, name string) (*archive.Document, *Report) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, report, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc, report
+}
+
+func TestParse(t *testing.T) {
+	doc, report := parse(t, "board.json")
+
+	var lists []string
+	for _, l := range doc.Board.Lists {
+		var cards []string
+		for _, c := range l.Cards {
+			cards = append(cards, c.Name)
+		}
+		lists = append(lists, l.Name+":"+strings.Join(cards, ","))
+	}
+	want := "To Do:Pick a colour scheme,Fix broken contact form Doing: Done:Set up hosting"
+	if got := strings.Join(lists, " "); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+
+	wantLabels := []backend.Label{{Name: "bug", Color: "red"}, {Name: "design", Color: "blue"}, {Name: "green", Color: "green"}}
+	if len(doc.Board.Labels) != len(wantLabels) {
+		t.Fatalf("want palette %v, got %v", wantLabels, doc.Board.Labels)
+	}
+	for i, l := range wantLabels {
+		if doc.Board.Labels[i] != l {
+			t.Fatalf("want palette %v, got %v", wantLabels, doc.Board.Labels)
+		}
+	}
+
+	colours := doc.Board.Lists[0].Cards[0]
+	if len(colours.Labels) != 2 || colours.Labels[1].Name != "green" {
+		t.Fatalf("unexpected labels %v", colours.Labels)
+	}
+	form := doc.Board.Lists[0].Cards[1]
+	wantDesc := strings.Join([]string{
+		"Submitting twice sends **two** mails.",
+		"## Due", "2023-05-04 15:00",
+		"## Steps", "- [x] Reproduce\n- [ ] Add a test",
+		"## Comments", "**grace** 2023-05-01 08:00", "Happens in Firefox only.",
+		"**Ada Lovelace** 2023-05-02 09:30", "Fixed on staging.",
+	}, "\n\n")
+	if form.Description != wantDesc {
+		t.Fatalf("want description\n%s\ngot\n%s", wantDesc, form.Description)
+	}
+	hosting := doc.Board.Lists[2].Cards[0]
+	if hosting.Description != "Static hosting with a CDN.\n\n## Due\n\n2023-04-01 10:00 (done)" {
+		t.Fatalf("unexpected description %q", hosting.Description)
+	}
+
+	if report.ClosedLists != 1 || report.ClosedCards != 1 || report.ClosedListCards != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for field, n := range map[string]int{"board.desc": 1, "board.members": 1, "card.idMembers": 1, "card.attachments": 1} {
+		if report.Unmapped[field] != n {
+			t.Errorf("want %s on %d record(s), got %d", field, n, report.Unmapped[field])
+		}
+	}
+	if len(report.Unmapped) != 4 {
+		t.Errorf("unexpected unmapped fields %v", report.Unmapped)
+	}
+}
+
+func TestParse_Import(t *testing.T) {
+	ctx := context.Background()
+	for _, name := range []string{"board.json", "minimal.json"} {
+		doc, _ := parse(t, name)
+		be := memory.New()
+		res, err := archive.Import(ctx, be, doc, archive.Create, "")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := archive.Import(ctx, be, doc, archive.Merge, ""); err != nil {
+			t.Fatalf("%s: reimport: %v", name, err)
+		}
+		lists, err := res.Board.Lists(ctx)
+		if err != nil || len(lists) != len(doc.Board.Lists) || lists[0].Order != backend.ManualOrder {
+			t.Fatalf("%s: unexpected lists %v %v", name, lists, err)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{`[]`, `{"name": "x"}`, `{"Version": 1, "Board": {"Name": "x"}}`, `{"name": "x", "lists": [], "cards": {}}`} {
+		if _, _, err := Parse(strings.NewReader(s)); !errors.Is(err, backend.ErrInvalid) {
+			t.Errorf("%s: want ErrInvalid, got %v", s, err)
+		}
+	}
+}
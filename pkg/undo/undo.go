@@ -0,0 +1,343 @@
+// Package undo keeps per-board undo and redo stacks of changes to lists
+// and cards. A change is recorded as the state of the records it touched
+// before and after, so it can be reverted and replayed on any backend.
+package undo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// MaxEntries is the number of changes kept on each stack of a board.
+const MaxEntries = 100
+
+var (
+	// ErrEmpty is returned when there is no change to undo or redo.
+	ErrEmpty = errors.New("no change to step to")
+	// ErrSave is returned when the history was changed but could not be
+	// written to its file. The change itself was made.
+	ErrSave = errors.New("could not save the undo history")
+)
+
+// Snapshot is the state of some lists and their cards at one point.
+type Snapshot struct {
+	Lists map[string]backend.List
+	Cards map[string]backend.Card
+}
+
+// Take snapshots the lists with ids and all of their cards.
+func Take(ctx context.Context, be backend.Backend, listIds ...string) (Snapshot, error) {
+	s := Snapshot{Lists: make(map[string]backend.List), Cards: make(map[string]backend.Card)}
+	for _, id := range listIds {
+		list, err := be.GetList(ctx, id)
+		if err != nil {
+			return s, err
+		}
+		s.Lists[id] = *list
+		cards, err := be.ListCards(ctx, id)
+		if err != nil {
+			return s, err
+		}
+		for _, c := range cards {
+			s.Cards[c.Id] = *c
+		}
+	}
+	return s, nil
+}
+
+// ListChange and CardChange hold a record before and after a change, with
+// nil for a record that did not exist.
+type ListChange struct {
+	Before, After *backend.List
+}
+
+type CardChange struct {
+	Before, After *backend.Card
+}
+
+// Entry is one undoable action.
+type Entry struct {
+	Name  string
+	Lists []ListChange
+	Cards []CardChange
+}
+
+// Diff returns the entry turning before into after, or false if nothing
+// changed.
+func Diff(name string, before, after Snapshot) (Entry, bool) {
+	e := Entry{Name: name}
+	for id := range union(keys(before.Lists), keys(after.Lists)) {
+		b, bok := before.Lists[id]
+		a, aok := after.Lists[id]
+		if bok && aok && b.Rev == a.Rev {
+			continue
+		}
+		c := ListChange{}
+		if bok {
+			c.Before = &b
+		}
+		if aok {
+			c.After = &a
+		}
+		e.Lists = append(e.Lists, c)
+	}
+	for id := range union(keys(before.Cards), keys(after.Cards)) {
+		b, bok := before.Cards[id]
+		a, aok := after.Cards[id]
+		if bok && aok && b.Rev == a.Rev {
+			continue
+		}
+		c := CardChange{}
+		if bok {
+			c.Before = &b
+		}
+		if aok {
+			c.After = &a
+		}
+		e.Cards = append(e.Cards, c)
+	}
+	return e, len(e.Lists) > 0 || len(e.Cards) > 0
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func union(a, b []string) map[string]struct{} {
+	out := make(map[string]struct{}, len(a)+len(b))
+	for _, k := range append(a, b...) {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+// apply moves every record of e from its After state to its Before state,
+// or the other way round when forward is set. Records that were changed by
+// anything else since fail the whole entry with backend.ErrConflict.
+// Records are compared by content as revisions restart when a deleted card
+// is restored.
+func (e *Entry) apply(ctx context.Context, be backend.Backend, forward bool) error {
+	return backend.RunTx(ctx, be, func(be backend.Backend) error {
+		for _, c := range e.Lists {
+			from, to := c.After, c.Before
+			if forward {
+				from, to = to, from
+			}
+			if err := applyList(ctx, be, from, to); err != nil {
+				return err
+			}
+		}
+		for _, c := range e.Cards {
+			from, to := c.After, c.Before
+			if forward {
+				from, to = to, from
+			}
+			if err := applyCard(ctx, be, from, to); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func changed(kind, id string) error {
+	return fmt.Errorf("%s %s changed since: %w", kind, id, backend.ErrConflict)
+}
+
+func applyList(ctx context.Context, be backend.Backend, from, to *backend.List) error {
+	if from == nil || to == nil {
+		// Lists are only ever updated by recorded actions.
+		return nil
+	}
+	current, err := be.GetList(ctx, from.Id)
+	if err != nil {
+		return err
+	}
+	if current.Name != from.Name || current.Pos != from.Pos || current.Order != from.Order {
+		return changed("list", from.Id)
+	}
+	to.Rev = current.Rev
+	return be.UpdateList(ctx, to)
+}
+
+func applyCard(ctx context.Context, be backend.Backend, from, to *backend.Card) error {
+	id := ""
+	if from != nil {
+		id = from.Id
+	} else {
+		id = to.Id
+	}
+	current, err := be.GetCard(ctx, id)
+	switch {
+	case errors.Is(err, backend.ErrNotFound):
+		if from != nil {
+			return changed("card", id)
+		}
+	case err != nil:
+		return err
+	case from == nil || !sameCard(current, from):
+		return changed("card", id)
+	}
+	switch {
+	case to == nil:
+		return be.DeleteCard(ctx, id)
+	case from == nil:
+		return be.AddCard(ctx, to)
+	}
+	to.Rev = current.Rev
+	return be.UpdateCard(ctx, to)
+}
+
+func sameCard(a, b *backend.Card) bool {
+	if len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for i := range a.Labels {
+		if a.Labels[i] != b.Labels[i] {
+			return false
+		}
+	}
+	return a.ListId == b.ListId && a.Name == b.Name && a.Description == b.Description &&
+		a.Value == b.Value && a.Effort == b.Effort && a.Work == b.Work && a.Pos == b.Pos
+}
+
+// Stacks are the changes of a board that can be undone and redone, the
+// most recent last.
+type Stacks struct {
+	Undo, Redo []Entry
+}
+
+// History holds the undo and redo stacks of every board, saved to a file
+// after each change when it has a path.
+type History struct {
+	path   string
+	mu     sync.Mutex
+	Boards map[string]*Stacks
+}
+
+// New returns a History that is not persisted.
+func New() *History {
+	return &History{Boards: make(map[string]*Stacks)}
+}
+
+// Open loads the History saved at path, starting empty if there is none.
+func Open(path string) (*History, error) {
+	h := New()
+	h.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("undo history %s: %w", path, err)
+	}
+	if h.Boards == nil {
+		h.Boards = make(map[string]*Stacks)
+	}
+	return h, nil
+}
+
+func (h *History) board(id string) *Stacks {
+	s, ok := h.Boards[id]
+	if !ok {
+		s = &Stacks{}
+		h.Boards[id] = s
+	}
+	return s
+}
+
+// Record pushes e onto the undo stack of board and clears its redo stack.
+func (h *History) Record(board string, e Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.board(board)
+	s.Undo = push(s.Undo, e)
+	s.Redo = nil
+	return h.save()
+}
+
+// Clear drops the undo and redo stacks of board, for changes that are not
+// recorded and that the recorded ones could not be applied over.
+func (h *History) Clear(board string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.Boards[board]; !ok {
+		return nil
+	}
+	delete(h.Boards, board)
+	return h.save()
+}
+
+// Undo reverts the last change recorded for board and returns its name.
+func (h *History) Undo(ctx context.Context, be backend.Backend, board string) (string, error) {
+	return h.step(ctx, be, board, false)
+}
+
+// Redo replays the last change undone on board and returns its name.
+func (h *History) Redo(ctx context.Context, be backend.Backend, board string) (string, error) {
+	return h.step(ctx, be, board, true)
+}
+
+func (h *History) step(ctx context.Context, be backend.Backend, board string, forward bool) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.board(board)
+	from, to := &s.Undo, &s.Redo
+	if forward {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		return "", ErrEmpty
+	}
+	e := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	// An entry that cannot be applied is dropped, so it does not block the
+	// entries below it.
+	if err := e.apply(ctx, be, forward); err != nil {
+		if serr := h.save(); serr != nil {
+			return e.Name, errors.Join(err, serr)
+		}
+		return e.Name, err
+	}
+	*to = push(*to, e)
+	return e.Name, h.save()
+}
+
+func push(entries []Entry, e Entry) []Entry {
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return entries
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSave, err)
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("%w: %v", ErrSave, err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("%w: %v", ErrSave, err)
+	}
+	return nil
+}
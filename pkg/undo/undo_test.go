@@ -0,0 +1,239 @@
+package undo
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/backend/memory"
+)
+
+func setup(t *testing.T) (backend.Backend, *backend.List, *backend.List) {
+	t.Helper()
+	ctx := context.Background()
+	be := memory.New()
+	if err := be.AddBoard(ctx, &backend.Board{Id: "b", Name: "board"}); err != nil {
+		t.Fatal(err)
+	}
+	todo := &backend.List{Id: "todo", BoardId: "b", Name: "TODO"}
+	done := &backend.List{Id: "done", BoardId: "b", Name: "DONE", Pos: 1}
+	for _, l := range []*backend.List{todo, done} {
+		if err := be.AddList(ctx, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return be, todo, done
+}
+
+// change records fn as a change to the lists with ids.
+func change(t *testing.T, h *History, be backend.Backend, fn func() error, ids ...string) {
+	t.Helper()
+	ctx := context.Background()
+	before, err := Take(ctx, be, ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fn(); err != nil {
+		t.Fatal(err)
+	}
+	after, err := Take(ctx, be, ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := Diff("change", before, after)
+	if !ok {
+		t.Fatal("no change recorded")
+	}
+	if err := h.Record("b", e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func cardName(t *testing.T, be backend.Backend, id string) string {
+	t.Helper()
+	card, err := be.GetCard(context.Background(), id)
+	if errors.Is(err, backend.ErrNotFound) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return card.Name
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	be, todo, done := setup(t)
+	h := New()
+
+	card := &backend.Card{Id: "c", ListId: todo.Id, Name: "first"}
+	change(t, h, be, func() error { return be.AddCard(ctx, card) }, todo.Id)
+	change(t, h, be, func() error {
+		card.Name = "renamed"
+		return be.UpdateCard(ctx, card)
+	}, todo.Id)
+	card.SetBackend(be)
+	change(t, h, be, func() error { return card.Move(ctx, done) }, todo.Id, done.Id)
+	change(t, h, be, func() error { return be.DeleteCard(ctx, card.Id) }, done.Id)
+
+	steps := []struct {
+		redo     bool
+		name     string
+		listId   string
+		gone     bool
+		wantName string
+	}{
+		{name: "undo delete", listId: done.Id, wantName: "renamed"},
+		{name: "undo move", listId: todo.Id, wantName: "renamed"},
+		{name: "undo update", listId: todo.Id, wantName: "first"},
+		{name: "undo add", gone: true},
+		{name: "redo add", redo: true, listId: todo.Id, wantName: "first"},
+		{name: "redo update", redo: true, listId: todo.Id, wantName: "renamed"},
+		{name: "redo move", redo: true, listId: done.Id, wantName: "renamed"},
+		{name: "redo delete", redo: true, gone: true},
+	}
+	for _, s := range steps {
+		step := h.Undo
+		if s.redo {
+			step = h.Redo
+		}
+		if _, err := step(ctx, be, "b"); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		got, err := be.GetCard(ctx, "c")
+		if s.gone {
+			if !errors.Is(err, backend.ErrNotFound) {
+				t.Fatalf("%s: want card gone, got %v", s.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got.Name != s.wantName || got.ListId != s.listId {
+			t.Fatalf("%s: got %q in %s", s.name, got.Name, got.ListId)
+		}
+	}
+	if _, err := h.Redo(ctx, be, "b"); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("want ErrEmpty, got %v", err)
+	}
+}
+
+func TestHistory_Conflict(t *testing.T) {
+	ctx := context.Background()
+	be, todo, done := setup(t)
+	h := New()
+
+	change(t, h, be, func() error {
+		return be.AddCard(ctx, &backend.Card{Id: "d", ListId: done.Id, Name: "other"})
+	}, done.Id)
+	card := &backend.Card{Id: "c", ListId: todo.Id, Name: "first"}
+	if err := be.AddCard(ctx, card); err != nil {
+		t.Fatal(err)
+	}
+	change(t, h, be, func() error {
+		card.Name = "second"
+		return be.UpdateCard(ctx, card)
+	}, todo.Id)
+	card.Name = "elsewhere"
+	if err := be.UpdateCard(ctx, card); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Undo(ctx, be, "b"); !errors.Is(err, backend.ErrConflict) {
+		t.Fatalf("want ErrConflict, got %v", err)
+	}
+	if got := cardName(t, be, "c"); got != "elsewhere" {
+		t.Fatalf("conflicting undo changed the card to %q", got)
+	}
+	// The failed change is dropped so the one below it can be undone.
+	if _, err := h.Undo(ctx, be, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cardName(t, be, "d"); got != "" {
+		t.Fatalf("card d should be removed, got %q", got)
+	}
+	if _, err := h.Redo(ctx, be, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Redo(ctx, be, "b"); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("want ErrEmpty, got %v", err)
+	}
+
+	if err := h.Clear("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Undo(ctx, be, "b"); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("want ErrEmpty after Clear, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	be, todo, _ := setup(t)
+	path := filepath.Join(t.TempDir(), "orga.db.undo")
+	h, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := &backend.Card{Id: "c", ListId: todo.Id, Name: "first"}
+	change(t, h, be, func() error { return be.AddCard(ctx, card) }, todo.Id)
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Undo(ctx, be, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cardName(t, be, "c"); got != "" {
+		t.Fatalf("want card removed, got %q", got)
+	}
+	again, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Boards["b"].Redo) != 1 {
+		t.Fatal("redo stack was not saved")
+	}
+	if _, err := New().Undo(ctx, be, "b"); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("want ErrEmpty, got %v", err)
+	}
+
+	unsaved, err := Open(filepath.Join(t.TempDir(), "missing", "orga.db.undo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unsaved.Record("b", Entry{Name: "change"}); !errors.Is(err, ErrSave) {
+		t.Fatalf("want ErrSave, got %v", err)
+	}
+	if _, err := unsaved.Undo(ctx, be, "b"); errors.Unwrap(err) != ErrSave {
+		t.Fatalf("want ErrSave alone, got %v", err)
+	}
+
+	// A failed undo reports the failed save too.
+	before, err := Take(ctx, be, todo.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &backend.Card{Id: "o", ListId: todo.Id, Name: "other"}
+	if err := be.AddCard(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	after, err := Take(ctx, be, todo.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := Diff("add", before, after)
+	if err := unsaved.Record("b", e); !errors.Is(err, ErrSave) {
+		t.Fatalf("want ErrSave, got %v", err)
+	}
+	other.Name = "elsewhere"
+	if err := be.UpdateCard(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unsaved.Undo(ctx, be, "b"); !errors.Is(err, backend.ErrConflict) || !errors.Is(err, ErrSave) {
+		t.Fatalf("want ErrConflict and ErrSave, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+const boardsHelp = "Enter Open | a Add | r Rename | c Duplicate | d Delete | Esc Back"
+
+// showBoards opens the board picker on the current board.
+func (v *View) showBoards() {
+	v.showBoardsAt(v.Board.Id)
+}
+
+// showBoardsAt lists every board of the backend with the one with id
+// selected, and lets the user open, add, rename, duplicate and delete them.
+func (v *View) showBoardsAt(id string) {
+	ctx := context.Background()
+	boards, err := v.Board.GetBackend().ListBoards(ctx)
+	if err != nil {
+		v.showError("Could not list boards", err)
+		return
+	}
+	sort.Slice(boards, func(i, j int) bool {
+		return boards[i].Name < boards[j].Name
+	})
+
+	picker := tview.NewList().ShowSecondaryText(false)
+	for i, board := range boards {
+		board := board
+		name := tview.Escape(board.Name)
+		if board.Id == v.Board.Id {
+			name += " (current)"
+		}
+		picker.AddItem(name, "", 0, func() {
+			v.openBoard(board)
+		})
+		if board.Id == id {
+			picker.SetCurrentItem(i)
+		}
+	}
+	picker.SetBorder(true).SetTitle(" Boards ")
+	picker.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		var board *backend.Board
+		if i := picker.GetCurrentItem(); i >= 0 && i < len(boards) {
+			board = boards[i]
+		}
+		switch event.Key() {
+		case tcell.KeyEscape:
+			v.showBoard()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'a':
+				v.addBoard(boards)
+				return nil
+			case 'r':
+				v.renameBoard(board, boards)
+				return nil
+			case 'c':
+				v.duplicateBoard(board, boards)
+				return nil
+			case 'd':
+				v.deleteBoard(board)
+				return nil
+			}
+		}
+		return event
+	})
+
+	help := tview.NewTextView().SetText(boardsHelp).SetTextAlign(tview.AlignCenter)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(picker, 0, 1, true).
+		AddItem(help, 1, 0, false)
+	v.SetRoot(centered(layout, 70, len(boards)+3), true)
+}
+
+func (v *View) openBoard(board *backend.Board) {
+	board.SetBackend(v.Board.GetBackend())
+	if err := v.SetBoard(context.Background(), board); err != nil {
+		v.showError(fmt.Sprintf("Could not open board '%s'", board.Name), err)
+	}
+}
+
+func (v *View) addBoard(boards []*backend.Board) {
+	v.showBoardForm(" New Board ", "", boards, func(name string) {
+		board := &backend.Board{Name: name}
+		if err := v.Board.GetBackend().AddBoard(context.Background(), board); err != nil {
+			v.showError(fmt.Sprintf("Could not add board '%s'", name), err)
+			return
+		}
+		v.showBoardsAt(board.Id)
+	})
+}
+
+func (v *View) renameBoard(board *backend.Board, boards []*backend.Board) {
+	if board == nil {
+		return
+	}
+	v.showBoardForm(" Rename Board ", board.Name, boards, func(name string) {
+		board.SetBackend(v.Board.GetBackend())
+		prev := board.Name
+		board.Name = name
+		if err := board.Update(context.Background()); err != nil {
+			board.Name = prev
+			v.showError(fmt.Sprintf("Could not rename board '%s'", prev), err)
+			return
+		}
+		if board.Id == v.Board.Id {
+			v.Board.Name, v.Board.Rev = board.Name, board.Rev
+			v.grid.SetTitle(v.boardTitle())
+		}
+		v.showBoardsAt(board.Id)
+	})
+}
+
+func (v *View) duplicateBoard(board *backend.Board, boards []*backend.Board) {
+	if board == nil {
+		return
+	}
+	v.showBoardForm(" Duplicate Board ", board.Name+" copy", boards, func(name string) {
+		board.SetBackend(v.Board.GetBackend())
+		clone, err := board.Clone(context.Background(), name)
+		if err != nil {
+			v.showError(fmt.Sprintf("Could not duplicate board '%s'", board.Name), err)
+			return
+		}
+		v.showBoardsAt(clone.Id)
+	})
+}
+
+// showBoardForm asks for a board name, calling save with it unless it is
+// empty or already taken by another board. Board names must be unique as
+// the run command opens boards by name.
+func (v *View) showBoardForm(title, name string, boards []*backend.Board, save func(string)) {
+	form := tview.NewForm()
+	original := name
+	form.AddInputField("Name", name, 40, nil, func(text string) {
+		name = text
+	}).
+		AddButton("Save", func() {
+			if name == "" {
+				return
+			}
+			for _, b := range boards {
+				if b.Name == name && name != original {
+					form.SetTitle(fmt.Sprintf(" Name '%s' is taken ", tview.Escape(name)))
+					return
+				}
+			}
+			save(name)
+		}).
+		AddButton("Cancel", v.showBoards)
+	form.SetCancelFunc(v.showBoards)
+	form.SetBorder(true).SetTitle(title)
+	v.SetRoot(centered(form, 60, 7), true)
+}
+
+// deleteBoard asks before deleting board with everything on it. The open
+// board cannot be deleted, so there is always one to return to.
+func (v *View) deleteBoard(board *backend.Board) {
+	if board == nil {
+		return
+	}
+	if board.Id == v.Board.Id {
+		modal := tview.NewModal().
+			SetText(fmt.Sprintf("Board '%s' is open. Switch to another board before deleting it.", tview.Escape(board.Name))).
+			AddButtons([]string{"OK"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				v.showBoardsAt(board.Id)
+			})
+		v.SetRoot(modal, false)
+		return
+	}
+
+	ctx := context.Background()
+	board.SetBackend(v.Board.GetBackend())
+	lists, err := board.Lists(ctx)
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not load board '%s'", board.Name), err)
+		return
+	}
+	cards := 0
+	for _, list := range lists {
+		if c, err := list.Cards(ctx); err == nil {
+			cards += len(c)
+		}
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Delete board '%s'? Its %d lists and %d cards will be deleted with it.", tview.Escape(board.Name), len(lists), cards)).
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel != "Delete" {
+				v.showBoardsAt(board.Id)
+				return
+			}
+			if err := board.Delete(ctx); err != nil {
+				v.showError(fmt.Sprintf("Could not delete board '%s'", board.Name), err)
+				return
+			}
+			v.forget(board.Id)
+			v.showBoards()
+		})
+	v.SetRoot(modal, false)
+}
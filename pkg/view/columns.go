@@ -0,0 +1,218 @@
+package view
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+const columnsHelp = "a Add | Enter Rename | d Delete | Shift+↑↓ Move | Esc Back"
+
+// showColumns opens the column management screen on the selected column.
+func (v *View) showColumns() {
+	v.showColumnsAt(v.currentCol)
+}
+
+// showColumnsAt lists the columns of the board with the one at index
+// selected, and lets the user add, rename, delete and reorder them.
+func (v *View) showColumnsAt(index int) {
+	ctx := context.Background()
+	picker := tview.NewList()
+	for i, list := range v.lists {
+		col := i
+		count := ""
+		if cards, err := list.Cards(ctx); err == nil {
+			count = fmt.Sprintf("%d cards", len(cards))
+		}
+		picker.AddItem(tview.Escape(list.Name), count, 0, func() {
+			v.renameColumn(col)
+		})
+	}
+	if index >= 0 && index < picker.GetItemCount() {
+		picker.SetCurrentItem(index)
+	}
+	picker.SetBorder(true).SetTitle(fmt.Sprintf(" Columns of %s ", tview.Escape(v.Board.Name)))
+	picker.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		col := picker.GetCurrentItem()
+		switch event.Key() {
+		case tcell.KeyEscape:
+			v.showBoard()
+			return nil
+		case tcell.KeyUp, tcell.KeyDown:
+			if !isShift(event) {
+				return event
+			}
+			if event.Key() == tcell.KeyUp {
+				v.moveColumn(col, -1)
+			} else {
+				v.moveColumn(col, 1)
+			}
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'a':
+				v.addColumn()
+				return nil
+			case 'd':
+				v.deleteColumn(col)
+				return nil
+			}
+		}
+		return event
+	})
+
+	help := tview.NewTextView().SetText(columnsHelp).SetTextAlign(tview.AlignCenter)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(picker, 0, 1, true).
+		AddItem(help, 1, 0, false)
+	v.SetRoot(centered(layout, 60, 2*len(v.lists)+3), true)
+}
+
+// columnsChanged rebuilds the board after a column was changed and returns
+// to the columns screen with index selected.
+func (v *View) columnsChanged(index int) {
+	if err := v.buildColumns(context.Background()); err != nil {
+		v.showError("Could not reload columns", err)
+		return
+	}
+	v.currentCol = index
+	if v.currentCol >= len(v.lists) {
+		v.currentCol = len(v.lists) - 1
+	}
+	if v.currentCol < 0 {
+		v.currentCol = 0
+	}
+	v.showColumnsAt(index)
+}
+
+func (v *View) addColumn() {
+	v.showColumnForm(" New Column ", "", func(name string) {
+		list := &backend.List{Name: name}
+		if n := len(v.lists); n > 0 {
+			list.Pos = v.lists[n-1].Pos + 1
+		}
+		if err := v.AddLists(context.Background(), list); err != nil {
+			v.showError(fmt.Sprintf("Could not add list '%s'", name), err)
+			return
+		}
+		v.columnsChanged(len(v.lists))
+	})
+}
+
+func (v *View) renameColumn(col int) {
+	if col < 0 || col >= len(v.lists) {
+		return
+	}
+	list := v.lists[col]
+	v.showColumnForm(" Rename Column ", list.Name, func(name string) {
+		prev := list.Name
+		list.Name = name
+		if err := list.Update(context.Background()); err != nil {
+			list.Name = prev
+			v.showError(fmt.Sprintf("Could not rename list '%s'", prev), err)
+			return
+		}
+		v.columnsChanged(col)
+	})
+}
+
+// showColumnForm asks for a column name, calling save with it unless it is
+// empty.
+func (v *View) showColumnForm(title, name string, save func(string)) {
+	form := tview.NewForm()
+	form.AddInputField("Name", name, 30, nil, func(text string) {
+		name = text
+	}).
+		AddButton("Save", func() {
+			if name == "" {
+				return
+			}
+			save(name)
+		}).
+		AddButton("Cancel", func() {
+			v.showColumnsAt(v.currentCol)
+		})
+	form.SetCancelFunc(func() {
+		v.showColumnsAt(v.currentCol)
+	})
+	form.SetBorder(true).SetTitle(title)
+	v.SetRoot(centered(form, 50, 7), true)
+}
+
+// moveColumn moves the column at col offset places left or right.
+func (v *View) moveColumn(col, offset int) {
+	target := col + offset
+	if col < 0 || col >= len(v.lists) || target < 0 || target >= len(v.lists) {
+		return
+	}
+	list := v.lists[col]
+	if err := v.Reorder(context.Background(), list, target); err != nil {
+		v.showError(fmt.Sprintf("Could not move list '%s'", list.Name), err)
+		return
+	}
+	v.columnsChanged(target)
+}
+
+// deleteColumn asks before deleting the column at col, offering to move its
+// cards to a neighbouring column rather than deleting them with it.
+func (v *View) deleteColumn(col int) {
+	if col < 0 || col >= len(v.lists) {
+		return
+	}
+	ctx := context.Background()
+	list := v.lists[col]
+	cards, err := list.Cards(ctx)
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not load list '%s'", list.Name), err)
+		return
+	}
+
+	var neighbour *backend.List
+	switch {
+	case col > 0:
+		neighbour = v.lists[col-1]
+	case col+1 < len(v.lists):
+		neighbour = v.lists[col+1]
+	}
+
+	deleteLabel, moveLabel := "Delete", ""
+	text := fmt.Sprintf("Delete empty list '%s'?", list.Name)
+	buttons := []string{deleteLabel, "Cancel"}
+	if len(cards) > 0 {
+		deleteLabel = fmt.Sprintf("Delete %d cards", len(cards))
+		text = fmt.Sprintf("Delete list '%s'? Its %d cards will be deleted with it.", list.Name, len(cards))
+		buttons = []string{deleteLabel, "Cancel"}
+		if neighbour != nil {
+			moveLabel = fmt.Sprintf("Move to '%s'", neighbour.Name)
+			text = fmt.Sprintf("Delete list '%s'? Its %d cards can be moved to '%s' or deleted with it.", list.Name, len(cards), neighbour.Name)
+			buttons = []string{moveLabel, deleteLabel, "Cancel"}
+		}
+	}
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons(buttons).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			var err error
+			switch {
+			case moveLabel != "" && buttonLabel == moveLabel:
+				err = list.DeleteMovingCards(ctx, neighbour)
+			case buttonLabel == deleteLabel:
+				err = list.Delete(ctx)
+			default:
+				v.showColumnsAt(col)
+				return
+			}
+			if err != nil {
+				v.showError(fmt.Sprintf("Could not delete list '%s'", list.Name), err)
+				return
+			}
+			v.forget(v.Board.Id)
+			v.columnsChanged(col)
+		})
+	v.SetRoot(modal, false)
+}
@@ -0,0 +1,61 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func newDetail() *tview.TextView {
+	detail := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true).
+		SetWordWrap(true)
+	detail.SetBorder(true).SetTitle(" Details ")
+	return detail
+}
+
+// toggleDetail shows or hides the pane with the details of the selected
+// card next to the lists.
+func (v *View) toggleDetail() {
+	v.detailShown = !v.detailShown
+	v.setupLayout()
+	v.updateDetail()
+}
+
+// updateDetail shows the selected card in the detail pane.
+func (v *View) updateDetail() {
+	if !v.detailShown {
+		return
+	}
+	v.detail.ScrollToBeginning()
+	card, _ := v.currentCard(context.Background())
+	if card == nil {
+		v.detail.SetText("[gray]No card selected[-]")
+		return
+	}
+	v.detail.SetText(v.cardDetail(card))
+}
+
+func (v *View) cardDetail(card *backend.Card) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[::b]%s[::-]\n", tview.Escape(card.Name))
+	if len(card.Labels) > 0 {
+		b.WriteString(v.labelTags(card.Labels) + "\n")
+	}
+	fmt.Fprintf(&b, "Value %d  Effort %d  Work %d\n", card.Value, card.Effort, card.Work)
+	if card.Effort > 0 {
+		b.WriteString(workProgress(card) + "\n")
+	}
+	if !card.LastUpdate.IsZero() {
+		fmt.Fprintf(&b, "[gray]Updated %s[-]\n", card.LastUpdate.Format("2006-01-02 15:04"))
+	}
+	if card.Description != "" {
+		b.WriteString("\n" + renderMarkdown(card.Description))
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/frontmatter"
+)
+
+// editorMeta is the front-matter of a card opened in $EDITOR. The
+// description follows it as Markdown.
+type editorMeta struct {
+	Name   string   `yaml:"Name"`
+	Value  int      `yaml:"Value"`
+	Effort int      `yaml:"Effort"`
+	Labels []string `yaml:"Labels,omitempty"`
+}
+
+func marshalCard(card *backend.Card) ([]byte, error) {
+	meta := editorMeta{Name: card.Name, Value: card.Value, Effort: card.Effort}
+	for _, l := range card.Labels {
+		meta.Labels = append(meta.Labels, l.Name)
+	}
+	return frontmatter.Marshal(meta, card.Description)
+}
+
+// unmarshalCard applies an edited card file to card, leaving it untouched
+// if the file does not parse or validate. Labels must be in the palette of
+// board or already be on the card.
+func unmarshalCard(data []byte, card *backend.Card, board *backend.Board) error {
+	var meta editorMeta
+	description, err := frontmatter.UnmarshalStrict(data, &meta)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	if strings.TrimSpace(meta.Name) == "" {
+		problems = append(problems, "Name is empty")
+	}
+	if meta.Value < 0 {
+		problems = append(problems, "Value is negative")
+	}
+	if meta.Effort < 0 {
+		problems = append(problems, "Effort is negative")
+	}
+	labels := make([]backend.Label, 0, len(meta.Labels))
+	for _, name := range meta.Labels {
+		label, ok := board.Label(name)
+		if !ok {
+			for _, l := range card.Labels {
+				if l.Name == name {
+					label, ok = l, true
+				}
+			}
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("label %q is not in the palette", name))
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	card.Name = strings.TrimSpace(meta.Name)
+	card.Description = description
+	card.Value, card.Effort = meta.Value, meta.Effort
+	card.Labels = labels
+	if len(labels) == 0 {
+		card.Labels = nil
+	}
+	return nil
+}
+
+// editor returns the command line of the user's editor.
+func editor() []string {
+	if args := strings.Fields(os.Getenv("EDITOR")); len(args) > 0 {
+		return args
+	}
+	return []string{"vi"}
+}
+
+// editCurrentCardExternally opens the selected card in $EDITOR.
+func (v *View) editCurrentCardExternally() {
+	ctx := context.Background()
+	card, list := v.currentCard(ctx)
+	if card == nil {
+		return
+	}
+	data, err := marshalCard(card)
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not open card '%s'", card.Name), err)
+		return
+	}
+	v.runEditor(ctx, card, list, data)
+}
+
+// runEditor suspends the application while the user edits data, then
+// saves it to card. Invalid edits can be reopened as they were left.
+func (v *View) runEditor(ctx context.Context, card *backend.Card, list *backend.List, data []byte) {
+	f, err := os.CreateTemp("", "orga-*.md")
+	if err != nil {
+		v.showError("Could not create a temporary file", err)
+		return
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		v.showError("Could not write a temporary file", err)
+		return
+	}
+
+	args := append(editor(), path)
+	v.Suspend(func() {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err = cmd.Run()
+	})
+	if err != nil {
+		v.showError(fmt.Sprintf("Editor %s failed", args[0]), err)
+		return
+	}
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		v.showError("Could not read the edited card", err)
+		return
+	}
+
+	if err := unmarshalCard(edited, card, v.Board); err != nil {
+		modal := tview.NewModal().
+			SetText(fmt.Sprintf("The edited card is invalid:\n%v", err)).
+			AddButtons([]string{"Edit again", "Discard"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				v.showBoard()
+				if buttonLabel == "Edit again" {
+					v.runEditor(ctx, card, list, edited)
+				}
+			})
+		v.SetRoot(modal, false)
+		return
+	}
+	card.SetBackend(v.Board.GetBackend())
+	v.updateCard(ctx, card, list)
+}
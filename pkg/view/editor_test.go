@@ -0,0 +1,56 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func TestEditorRoundTrip(t *testing.T) {
+	board := &backend.Board{Labels: []backend.Label{{Name: "bug", Color: "red"}, {Name: "ui", Color: "blue"}}}
+	card := &backend.Card{
+		Name:        "Crash",
+		Description: "# Steps\n\n- open\n- click",
+		Value:       3,
+		Effort:      2,
+		Labels:      []backend.Label{{Name: "bug", Color: "red"}},
+	}
+	data, err := marshalCard(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "Value: 3", "Value: 5", 1)
+	edited = strings.Replace(edited, "- bug", "- bug\n  - ui", 1)
+	edited += "- retry\n"
+	if err := unmarshalCard([]byte(edited), card, board); err != nil {
+		t.Fatal(err)
+	}
+	if card.Value != 5 || len(card.Labels) != 2 || card.Labels[1].Color != "blue" {
+		t.Fatalf("edits not applied: %+v", card)
+	}
+	if card.Description != "# Steps\n\n- open\n- click\n- retry" {
+		t.Fatalf("unexpected description: %q", card.Description)
+	}
+}
+
+func TestEditorValidation(t *testing.T) {
+	board := &backend.Board{}
+	cases := map[string]string{
+		"---\nName: \"\"\n---\n":              "Name is empty",
+		"---\nName: a\nEffort: -1\n---\n":     "Effort is negative",
+		"---\nName: a\nLabels: [nope]\n---\n": `label "nope"`,
+		"---\nName: a\nVaule: 1\n---\n":       "Vaule",
+		"Name: a\n":                           "missing front-matter",
+	}
+	for data, want := range cases {
+		card := &backend.Card{Name: "keep"}
+		err := unmarshalCard([]byte(data), card, board)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: want error containing %q, got %v", data, want, err)
+		}
+		if card.Name != "keep" {
+			t.Errorf("%q: card changed on error: %+v", data, card)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// labelColors are the colours offered for palette labels, as tview colour
+// tag names.
+var labelColors = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray"}
+
+const labelsHelp = "a Add | Enter Edit | d Delete | Esc Back"
+
+// labelTags renders labels as coloured tags. Labels in the palette of the
+// board are drawn in the palette colour so recolouring applies everywhere.
+func (v *View) labelTags(labels []backend.Label) string {
+	tags := make([]string, len(labels))
+	for i, l := range labels {
+		color := l.Color
+		if p, ok := v.Board.Label(l.Name); ok {
+			color = p.Color
+		}
+		if color == "" {
+			color = "white"
+		}
+		tags[i] = fmt.Sprintf("[%s]%s[-]", color, tview.Escape(l.Name))
+	}
+	return strings.Join(tags, " ")
+}
+
+// showLabelFilter lets the user limit the board to cards with one label.
+func (v *View) showLabelFilter() {
+	picker := tview.NewList().ShowSecondaryText(false)
+	picker.AddItem("(all cards)", "", 0, func() {
+		v.setLabelFilter("")
+	})
+	for i, l := range v.Board.Labels {
+		name := l.Name
+		picker.AddItem(v.labelTags([]backend.Label{l}), "", 0, func() {
+			v.setLabelFilter(name)
+		})
+		if name == v.labelFilter {
+			picker.SetCurrentItem(i + 1)
+		}
+	}
+	picker.SetDoneFunc(v.showBoard)
+	picker.SetBorder(true).SetTitle(" Filter by label ")
+	v.SetRoot(centered(picker, 40, len(v.Board.Labels)+3), true)
+}
+
+func (v *View) setLabelFilter(name string) {
+	v.labelFilter = name
+	v.grid.SetTitle(v.boardTitle())
+	v.refreshBoard()
+	v.showBoard()
+}
+
+// showLabels opens the label palette of the board with the label at index
+// selected.
+func (v *View) showLabels(index int) {
+	picker := tview.NewList()
+	for i, l := range v.Board.Labels {
+		i := i
+		picker.AddItem(v.labelTags([]backend.Label{l}), l.Color, 0, func() {
+			v.editLabel(i)
+		})
+	}
+	if index >= 0 && index < picker.GetItemCount() {
+		picker.SetCurrentItem(index)
+	}
+	picker.SetBorder(true).SetTitle(fmt.Sprintf(" Labels of %s ", tview.Escape(v.Board.Name)))
+	picker.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			v.showBoard()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'a':
+				v.addLabel()
+				return nil
+			case 'd':
+				v.deleteLabel(picker.GetCurrentItem())
+				return nil
+			}
+		}
+		return event
+	})
+
+	help := tview.NewTextView().SetText(labelsHelp).SetTextAlign(tview.AlignCenter)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(picker, 0, 1, true).
+		AddItem(help, 1, 0, false)
+	v.SetRoot(centered(layout, 50, 2*len(v.Board.Labels)+3), true)
+}
+
+// labelsChanged redraws the board after the palette was changed and
+// returns to the palette with index selected.
+func (v *View) labelsChanged(index int) {
+	v.grid.SetTitle(v.boardTitle())
+	v.refreshBoard()
+	v.showLabels(index)
+}
+
+func (v *View) addLabel() {
+	v.showLabelForm(" New Label ", backend.Label{Color: labelColors[0]}, func(label backend.Label) error {
+		if err := v.Board.AddLabel(context.Background(), label); err != nil {
+			return err
+		}
+		v.labelsChanged(len(v.Board.Labels) - 1)
+		return nil
+	})
+}
+
+func (v *View) editLabel(index int) {
+	if index < 0 || index >= len(v.Board.Labels) {
+		return
+	}
+	old := v.Board.Labels[index]
+	v.showLabelForm(" Edit Label ", old, func(label backend.Label) error {
+		if err := v.Board.UpdateLabel(context.Background(), old.Name, label); err != nil {
+			return err
+		}
+		v.forget(v.Board.Id)
+		if v.labelFilter == old.Name {
+			v.labelFilter = label.Name
+		}
+		v.labelsChanged(index)
+		return nil
+	})
+}
+
+func (v *View) deleteLabel(index int) {
+	if index < 0 || index >= len(v.Board.Labels) {
+		return
+	}
+	label := v.Board.Labels[index]
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Delete label '%s'? It will be removed from every card on the board.", tview.Escape(label.Name))).
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel != "Delete" {
+				v.showLabels(index)
+				return
+			}
+			if err := v.Board.DeleteLabel(context.Background(), label.Name); err != nil {
+				v.showError(fmt.Sprintf("Could not delete label '%s'", label.Name), err)
+				return
+			}
+			v.forget(v.Board.Id)
+			if v.labelFilter == label.Name {
+				v.labelFilter = ""
+			}
+			v.labelsChanged(index)
+		})
+	v.SetRoot(modal, false)
+}
+
+// showLabelForm asks for the name and colour of a label and passes them to
+// save. Names already in the palette are reported in the form title.
+func (v *View) showLabelForm(title string, label backend.Label, save func(backend.Label) error) {
+	form := tview.NewForm()
+	original := label.Name
+	color := 0
+	for i, c := range labelColors {
+		if c == label.Color {
+			color = i
+		}
+	}
+	form.AddInputField("Name", label.Name, 30, nil, func(text string) {
+		label.Name = text
+	}).
+		AddDropDown("Color", labelColors, color, func(option string, index int) {
+			label.Color = option
+		}).
+		AddButton("Save", func() {
+			if label.Name == "" {
+				return
+			}
+			if _, ok := v.Board.Label(label.Name); ok && label.Name != original {
+				form.SetTitle(fmt.Sprintf(" Label '%s' exists ", tview.Escape(label.Name)))
+				return
+			}
+			if err := save(label); err != nil {
+				v.showError("Could not save label", err)
+			}
+		}).
+		AddButton("Cancel", func() {
+			v.showLabels(0)
+		})
+	form.SetCancelFunc(func() {
+		v.showLabels(0)
+	})
+	form.SetBorder(true).SetTitle(title)
+	v.SetRoot(centered(form, 50, 9), true)
+}
+
+// addLabelFields adds a checkbox for each label of the palette, and for any
+// other label card already has, to form. The returned function gives the
+// checked labels.
+func (v *View) addLabelFields(form *tview.Form, card *backend.Card) func() []backend.Label {
+	options := append([]backend.Label(nil), v.Board.Labels...)
+	if card != nil {
+		for _, l := range card.Labels {
+			if _, ok := v.Board.Label(l.Name); !ok {
+				options = append(options, l)
+			}
+		}
+	}
+	checked := make([]bool, len(options))
+	for i, l := range options {
+		i := i
+		checked[i] = card != nil && card.HasLabel(l.Name)
+		form.AddCheckbox(tview.Escape(l.Name), checked[i], func(on bool) {
+			checked[i] = on
+		})
+	}
+	return func() []backend.Label {
+		var labels []backend.Label
+		for i, l := range options {
+			if checked[i] {
+				labels = append(labels, l)
+			}
+		}
+		return labels
+	}
+}
@@ -0,0 +1,95 @@
+package view
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRe = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	quoteRe   = regexp.MustCompile(`^>\s?(.*)$`)
+	ruleRe    = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})\s*$`)
+	inlineRe  = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*]+)\\*")
+)
+
+// renderMarkdown converts the Markdown subset used in card descriptions,
+// namely headings, lists, quotes, rules, fenced code blocks and inline
+// code, bold and *italics*, to tview colour tags. Single underscores are
+// left alone as they are common in identifiers.
+func renderMarkdown(src string) string {
+	var out []string
+	code := false
+	for _, line := range strings.Split(strings.TrimRight(src, "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			code = !code
+			continue
+		}
+		if code {
+			out = append(out, "[gray]  "+tview.Escape(line)+"[-]")
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			style := "[yellow::b]"
+			if len(m[1]) == 1 {
+				style = "[yellow::bu]"
+			}
+			out = append(out, style+tview.Escape(m[2])+"[-::-]")
+			continue
+		}
+		if m := bulletRe.FindStringSubmatch(line); m != nil && !ruleRe.MatchString(line) {
+			out = append(out, m[1]+"• "+renderInline(m[2]))
+			continue
+		}
+		if m := orderedRe.FindStringSubmatch(line); m != nil {
+			out = append(out, m[1]+m[2]+". "+renderInline(m[3]))
+			continue
+		}
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			out = append(out, "[gray]│ "+renderInline(m[1])+"[-]")
+			continue
+		}
+		if ruleRe.MatchString(line) {
+			out = append(out, "[gray]"+strings.Repeat("─", 20)+"[-]")
+			continue
+		}
+		out = append(out, renderInline(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+func renderInline(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlineRe.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(tview.Escape(s[last:m[0]]))
+		switch {
+		case m[2] >= 0:
+			b.WriteString("[aqua]" + tview.Escape(s[m[2]:m[3]]) + "[-]")
+		case m[4] >= 0:
+			b.WriteString("[::b]" + tview.Escape(s[m[4]:m[5]]) + "[::-]")
+		case m[6] >= 0:
+			b.WriteString("[::b]" + tview.Escape(s[m[6]:m[7]]) + "[::-]")
+		default:
+			b.WriteString("[::i]" + tview.Escape(s[m[8]:m[9]]) + "[::-]")
+		}
+		last = m[1]
+	}
+	b.WriteString(tview.Escape(s[last:]))
+	return b.String()
+}
+
+// summary is the first non-empty line of a description, shown in the list
+// item with Markdown heading markers stripped.
+func summary(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package view
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := map[string]string{
+		"# Title":                   "[yellow::bu]Title[-::-]",
+		"## Steps":                  "[yellow::b]Steps[-::-]",
+		"- one\n  * two":            "• one\n  • two",
+		"1. first":                  "1. first",
+		"> quoted":                  "[gray]│ quoted[-]",
+		"---":                       "[gray]────────────────────[-]",
+		"run `make` **now**":        "run [aqua]make[-] [::b]now[::-]",
+		"*soft* and snake_case_id":  "[::i]soft[::-] and snake_case_id",
+		"```\n# not a heading\n```": "[gray]  # not a heading[-]",
+		"see [red]":                 "see [red[]",
+	}
+	for src, want := range cases {
+		if got := renderMarkdown(src); got != want {
+			t.Errorf("%q: want %q, got %q", src, want, got)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got := summary("\n## Context\nmore"); got != "Context" {
+		t.Fatalf("want Context, got %q", got)
+	}
+}
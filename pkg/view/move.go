@@ -0,0 +1,101 @@
+package view
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// moveCurrentCard moves the selected card offset columns to the left or
+// right, following it with the selection.
+func (v *View) moveCurrentCard(offset int) {
+	target := v.currentCol + offset
+	if target < 0 || target >= len(v.lists) {
+		return
+	}
+	v.moveCurrentCardTo(target)
+}
+
+func (v *View) moveCurrentCardTo(col int) {
+	ctx := context.Background()
+	card, _ := v.currentCard(ctx)
+	if card == nil || col == v.currentCol {
+		return
+	}
+	target := v.lists[col]
+	err := v.record(ctx, fmt.Sprintf("move '%s'", card.Name), []string{card.ListId, target.Id}, func() error {
+		return card.Move(ctx, target)
+	})
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not move card '%s'", card.Name), err)
+		return
+	}
+	v.refreshBoard()
+	v.highlightColumn(col)
+	v.selectCard(ctx, col, card.Id)
+}
+
+// selectCard moves the selection in column col to the card with id.
+func (v *View) selectCard(ctx context.Context, col int, id string) {
+	cards, err := v.visibleCards(ctx, v.lists[col])
+	if err != nil {
+		return
+	}
+	for i, c := range cards {
+		if c.Id == id {
+			v.listViews[col].SetCurrentItem(i)
+			return
+		}
+	}
+}
+
+// showListPicker lets the user choose the list to move the selected card to.
+func (v *View) showListPicker() {
+	card, _ := v.currentCard(context.Background())
+	if card == nil {
+		return
+	}
+
+	picker := tview.NewList().ShowSecondaryText(false)
+	for i, list := range v.lists {
+		col := i
+		picker.AddItem(tview.Escape(list.Name), "", 0, func() {
+			v.showBoard()
+			v.moveCurrentCardTo(col)
+		})
+	}
+	picker.SetCurrentItem(v.currentCol)
+	picker.SetDoneFunc(v.showBoard)
+	picker.SetBorder(true).SetTitle(fmt.Sprintf(" Move '%s' to ", tview.Escape(card.Name)))
+
+	v.SetRoot(centered(picker, 40, len(v.lists)+2), true)
+}
+
+// showError reports a failed action in a dialog that returns to the board.
+func (v *View) showError(msg string, err error) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("%s: %v", msg, err)).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			v.showBoard()
+		})
+	v.SetRoot(modal, false)
+}
+
+// centered wraps p in a layout that shows it at the given size in the
+// middle of the screen.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+func isShift(event *tcell.EventKey) bool {
+	return event.Modifiers()&tcell.ModShift != 0
+}
@@ -0,0 +1,94 @@
+package view
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func listTitle(list *backend.List) string {
+	if list.Order == backend.ManualOrder {
+		return fmt.Sprintf(" %s (manual) ", tview.Escape(list.Name))
+	}
+	return fmt.Sprintf(" %s ", tview.Escape(list.Name))
+}
+
+// reorderCurrentCard moves the selected card offset places up or down its
+// list, switching the list to manual order if needed.
+func (v *View) reorderCurrentCard(offset int) {
+	ctx := context.Background()
+	card, list := v.currentCard(ctx)
+	if card == nil {
+		return
+	}
+	target := v.listViews[v.currentCol].GetCurrentItem() + offset
+	err := v.record(ctx, fmt.Sprintf("reorder '%s'", card.Name), []string{list.Id}, func() error {
+		if err := list.SetOrder(ctx, backend.ManualOrder); err != nil {
+			return err
+		}
+		// Positions may have been rewritten, so reload the cards.
+		visible, err := v.visibleCards(ctx, list)
+		if err != nil {
+			return err
+		}
+		if target < 0 || target >= len(visible) {
+			return nil
+		}
+		// Place the card next to the visible card it swaps with, which may
+		// be further away in the list when cards are filtered out.
+		cards, err := list.Cards(ctx)
+		if err != nil {
+			return err
+		}
+		index := 0
+		for _, c := range cards {
+			if c.Id == visible[target].Id {
+				break
+			}
+			if c.Id != card.Id {
+				index++
+			}
+		}
+		if offset > 0 {
+			index++
+		}
+		for _, c := range cards {
+			if c.Id == card.Id {
+				card = c
+			}
+		}
+		return list.Reorder(ctx, card, index)
+	})
+	v.listViews[v.currentCol].SetTitle(listTitle(list))
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not reorder card '%s'", card.Name), err)
+		return
+	}
+	v.refreshBoard()
+	v.selectCard(ctx, v.currentCol, card.Id)
+}
+
+// toggleOrder switches the selected list between priority and manual order.
+func (v *View) toggleOrder() {
+	if v.currentCol >= len(v.lists) {
+		return
+	}
+	list := v.lists[v.currentCol]
+	order := backend.ManualOrder
+	if list.Order == backend.ManualOrder {
+		order = backend.PriorityOrder
+	}
+	ctx := context.Background()
+	err := v.record(ctx, fmt.Sprintf("order of '%s'", list.Name), []string{list.Id}, func() error {
+		return list.SetOrder(ctx, order)
+	})
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not change order of list '%s'", list.Name), err)
+		return
+	}
+	v.listViews[v.currentCol].SetTitle(listTitle(list))
+	v.refreshBoard()
+}
@@ -0,0 +1,86 @@
+package view
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/twistedogic/orga/pkg/backend"
+	"github.com/twistedogic/orga/pkg/query"
+)
+
+// visibleCards returns the cards of list shown on the board, in order.
+func (v *View) visibleCards(ctx context.Context, list *backend.List) ([]*backend.Card, error) {
+	cards, err := list.Cards(ctx)
+	if err != nil || v.labelFilter == "" && v.query.Empty() {
+		return cards, err
+	}
+	visible := cards[:0]
+	for _, c := range cards {
+		if v.labelFilter != "" && !c.HasLabel(v.labelFilter) {
+			continue
+		}
+		if v.query.Match(c, list) {
+			visible = append(visible, c)
+		}
+	}
+	return visible, nil
+}
+
+func (v *View) boardTitle() string {
+	title := tview.Escape(v.Board.Name)
+	if v.labelFilter != "" {
+		title += fmt.Sprintf(" (label: %s)", tview.Escape(v.labelFilter))
+	}
+	if v.queryText != "" {
+		title += fmt.Sprintf(" (search: %s)", tview.Escape(v.queryText))
+	}
+	return fmt.Sprintf(" %s ", title)
+}
+
+func (v *View) newSearch() *tview.InputField {
+	search := tview.NewInputField().SetLabel("/ ")
+	search.SetBorder(true).SetTitle(" Search: text label:x list:x value>n effort<=n work=n | Enter Keep | Esc Clear ")
+	search.SetChangedFunc(func(text string) {
+		q, err := query.Parse(text)
+		if err != nil {
+			// Keep the last valid filter while the query is being typed.
+			search.SetLabelColor(tcell.ColorRed)
+			return
+		}
+		search.SetLabelColor(tcell.ColorYellow)
+		v.query, v.queryText = q, text
+		v.grid.SetTitle(v.boardTitle())
+		v.refreshBoard()
+	})
+	search.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			search.SetText("")
+		}
+		v.stopSearch()
+	})
+	return search
+}
+
+// startSearch shows the search prompt in place of the footer. Cards are
+// filtered live as the query is typed.
+func (v *View) startSearch() {
+	v.searching = true
+	v.setupLayout()
+	v.SetFocus(v.search)
+}
+
+func (v *View) stopSearch() {
+	v.searching = false
+	v.setupLayout()
+	v.showBoard()
+}
+
+func (v *View) bottom() tview.Primitive {
+	if v.searching {
+		return v.search
+	}
+	return v.footer
+}
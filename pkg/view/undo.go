@@ -0,0 +1,81 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twistedogic/orga/pkg/undo"
+)
+
+// SetHistory makes the view record its changes to h, which may hold the
+// changes of earlier sessions.
+func (v *View) SetHistory(h *undo.History) {
+	v.history = h
+}
+
+// record runs fn, which changes the cards of the lists with listIds, and
+// adds the change to the undo history under name.
+func (v *View) record(ctx context.Context, name string, listIds []string, fn func() error) error {
+	be := v.Board.GetBackend()
+	before, err := undo.Take(ctx, be, listIds...)
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	after, err := undo.Take(ctx, be, listIds...)
+	if err != nil {
+		return err
+	}
+	if e, ok := undo.Diff(name, before, after); ok {
+		if err := v.history.Record(v.Board.Id, e); err != nil {
+			v.warnHistory(err)
+		}
+	}
+	return nil
+}
+
+// forget clears the history of board after a change that is not recorded,
+// such as deleting a list or relabelling every card, as the recorded
+// changes would be applied over state they were not taken from.
+func (v *View) forget(board string) {
+	if err := v.history.Clear(board); err != nil {
+		v.warnHistory(err)
+	}
+}
+
+// warnHistory reports that the history could not be saved once the change
+// that was made has been shown. The report is queued from a goroutine as
+// QueueUpdateDraw waits for the event loop, which is running the caller.
+func (v *View) warnHistory(err error) {
+	go v.QueueUpdateDraw(func() {
+		v.showError("The change was made", err)
+	})
+}
+
+// undoChange reverts the last recorded change on the board, or replays the last
+// reverted one when redo is set.
+func (v *View) undoChange(redo bool) {
+	ctx := context.Background()
+	step, verb := v.history.Undo, "undo"
+	if redo {
+		step, verb = v.history.Redo, "redo"
+	}
+	name, err := step(ctx, v.Board.GetBackend(), v.Board.Id)
+	if errors.Is(err, undo.ErrEmpty) {
+		return
+	}
+	v.rebuildColumns(ctx)
+	v.refreshBoard()
+	// A change that was made but not saved to the history wraps ErrSave
+	// alone, while one that could not be made may be joined with it.
+	if errors.Unwrap(err) == undo.ErrSave {
+		v.warnHistory(err)
+		return
+	}
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not %s '%s'", verb, name), err)
+	}
+}
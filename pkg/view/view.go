@@ -2,8 +2,10 @@ package view
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/google/uuid"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/twistedogic/orga/pkg/backend"
 	"github.com/twistedogic/orga/pkg/config"
+	"github.com/twistedogic/orga/pkg/query"
+	"github.com/twistedogic/orga/pkg/undo"
 )
 
 type View struct {
@@ -24,14 +28,24 @@ type View struct {
 	listViews  []*tview.List
 	currentCol int
 	footer     *tview.TextView
+	// labelFilter limits the board to cards with this label when set.
+	labelFilter string
+	search      *tview.InputField
+	searching   bool
+	detail      *tview.TextView
+	detailShown bool
+	query       query.Query
+	queryText   string
+	history     *undo.History
 }
 
-func New(ctx context.Context, board *backend.Board) (View, error) {
-	v := View{
+func New(ctx context.Context, board *backend.Board) (*View, error) {
+	v := &View{
 		Context:     ctx,
 		Application: tview.NewApplication(),
 		Board:       board,
 		currentCol:  0,
+		history:     undo.New(),
 	}
 	err := v.Init(ctx)
 	return v, err
@@ -62,74 +76,122 @@ func (v *View) Init(ctx context.Context) error {
 	return v.buildUI(ctx)
 }
 
-func (v *View) buildUI(ctx context.Context) error {
-	// Get lists from backend
-	lists, err := v.Lists(ctx)
-	if err != nil {
+// SetBoard switches the view to board, which gets the default lists if it
+// has none, without restarting the application.
+func (v *View) SetBoard(ctx context.Context, board *backend.Board) error {
+	prev := v.Board
+	v.Board = board
+	if err := v.bootstrap(ctx); err != nil {
+		v.Board = prev
 		return err
 	}
-	v.lists = lists
+	v.currentCol, v.labelFilter = 0, ""
+	v.query, v.queryText = query.Query{}, ""
+	v.search.SetText("")
+	if err := v.buildColumns(ctx); err != nil {
+		return err
+	}
+	v.grid.SetTitle(v.boardTitle())
+	v.showBoard()
+	return nil
+}
 
+func (v *View) buildUI(ctx context.Context) error {
 	// Create grid layout
 	v.grid = tview.NewGrid()
-	v.grid.SetBorder(true).SetTitle(fmt.Sprintf(" %s ", v.Board.Name))
+	v.grid.SetBorder(true).SetTitle(v.boardTitle())
 
 	// Create footer
 	v.footer = tview.NewTextView().
-		SetText("Navigation: ←→ Move between lists | ↑↓ Move between cards | Enter Edit card | n New card | d Delete card | q Quit").
+		SetText("Navigation: ←→ Move between lists | ↑↓ Move between cards | Enter Edit card | e $EDITOR | n New card | d Delete card | Shift+←→/m Move card | Shift+↑↓ Reorder | o Order mode | c Columns | b Boards | l Labels | f Filter | / Search | i Details | w/W Log work | u/Ctrl+R Undo/Redo | q Quit").
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
-	// Create list views
-	v.listViews = make([]*tview.List, len(lists))
-	for i, list := range lists {
-		v.listViews[i] = v.createListView(ctx, list, i)
-	}
+	v.search = v.newSearch()
+	v.detail = newDetail()
 
-	// Set up grid layout
-	v.setupLayout()
+	if err := v.buildColumns(ctx); err != nil {
+		return err
+	}
 
 	// Set up key bindings
 	v.setupKeyBindings()
 
-	// Highlight first column
-	v.highlightColumn(0)
+	v.showBoard()
 
 	return nil
 }
 
+// buildColumns reloads the lists of the board and lays out a column for
+// each of them, keeping the selected column where possible.
+func (v *View) buildColumns(ctx context.Context) error {
+	lists, err := v.Lists(ctx)
+	if err != nil {
+		return err
+	}
+	v.lists = lists
+
+	v.listViews = make([]*tview.List, len(lists))
+	for i, list := range lists {
+		v.listViews[i] = v.createListView(ctx, list, i)
+	}
+	if v.currentCol >= len(lists) {
+		v.currentCol = len(lists) - 1
+	}
+	if v.currentCol < 0 {
+		v.currentCol = 0
+	}
+
+	v.setupLayout()
+	return nil
+}
+
 func (v *View) createListView(ctx context.Context, list *backend.List, index int) *tview.List {
 	listView := tview.NewList()
 	listView.ShowSecondaryText(true).
 		SetBorder(true).
-		SetTitle(fmt.Sprintf(" %s ", list.Name))
+		SetTitle(listTitle(list))
 
 	// Load cards for this list
 	if err := v.loadCards(ctx, listView, list); err != nil {
 		// Show error in list
 		listView.AddItem("Error loading cards", err.Error(), 0, nil)
 	}
+	listView.SetChangedFunc(func(int, string, string, rune) {
+		if v.currentCol < len(v.listViews) && v.listViews[v.currentCol] == listView {
+			v.updateDetail()
+		}
+	})
 
 	return listView
 }
 
 func (v *View) loadCards(ctx context.Context, listView *tview.List, list *backend.List) error {
-	cards, err := list.Cards(ctx)
+	cards, err := v.visibleCards(ctx, list)
 	if err != nil {
 		return err
 	}
 
+	// Keep the selection when the list is redrawn in place.
+	current := listView.GetCurrentItem()
 	listView.Clear()
 	for _, card := range cards {
-		primary := card.Name
-		secondary := ""
-		if card.Description != "" {
-			secondary = card.Description
-		}
+		primary := tview.Escape(card.Name)
+		secondary := tview.Escape(summary(card.Description))
 		if card.Value > 0 || card.Effort > 0 {
 			secondary += fmt.Sprintf(" [Value:%d Effort:%d]", card.Value, card.Effort)
 		}
-		
+		// Lead with progress as narrow columns cut off the end.
+		if card.Effort > 0 || card.Work > 0 {
+			secondary = strings.TrimSpace(workProgress(card) + " " + strings.TrimSpace(secondary))
+		}
+		if card.OverBudget() {
+			primary = "[red]" + primary + "[-]"
+		}
+		if len(card.Labels) > 0 {
+			primary += " " + v.labelTags(card.Labels)
+		}
+
 		listView.AddItem(primary, secondary, 0, func() {
 			v.editCard(ctx, card)
 		})
@@ -138,13 +200,20 @@ func (v *View) loadCards(ctx context.Context, listView *tview.List, list *backen
 	if listView.GetItemCount() == 0 {
 		listView.AddItem("(empty)", "Press 'n' to add a new card", 0, nil)
 	}
+	if current < listView.GetItemCount() {
+		listView.SetCurrentItem(current)
+	}
 
 	return nil
 }
 
 func (v *View) setupLayout() {
+	v.grid.Clear()
 	numCols := len(v.lists)
 	if numCols == 0 {
+		// Keep the footer so the columns screen is still discoverable.
+		v.grid.SetRows(0, 3).SetColumns(0)
+		v.grid.AddItem(v.bottom(), 1, 0, 1, 1, 0, 0, false)
 		return
 	}
 
@@ -157,25 +226,41 @@ func (v *View) setupLayout() {
 	for i := range cols {
 		cols[i] = colWidth
 	}
+	if v.detailShown {
+		// Share the width so the pane fits on narrow terminals, giving it
+		// twice the room of a list.
+		for i := range cols {
+			cols[i] = -1
+		}
+		cols = append(cols, -2)
+	}
 	v.grid.SetColumns(cols...)
 
 	// Add list views to grid
 	for i, listView := range v.listViews {
 		v.grid.AddItem(listView, 0, i, 1, 1, 0, 0, false)
 	}
+	if v.detailShown {
+		v.grid.AddItem(v.detail, 0, numCols, 1, 1, 0, 0, false)
+	}
 
-	// Add footer
-	v.grid.AddItem(v.footer, 1, 0, 1, numCols, 0, 0, false)
-
-	v.SetRoot(v.grid, true)
+	// Add footer, or the search prompt while searching
+	v.grid.AddItem(v.bottom(), 1, 0, 1, len(cols), 0, 0, false)
 }
 
 func (v *View) setupKeyBindings() {
 	v.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Leave keys to forms and dialogs while they are shown.
+		if !v.boardFocused() {
+			return event
+		}
 		switch event.Key() {
 		case tcell.KeyEscape, tcell.KeyCtrlC:
 			v.Stop()
 			return nil
+		case tcell.KeyCtrlR:
+			v.undoChange(true)
+			return nil
 		case tcell.KeyRune:
 			switch event.Rune() {
 			case 'q':
@@ -190,12 +275,66 @@ func (v *View) setupKeyBindings() {
 			case 'r':
 				v.refreshBoard()
 				return nil
+			case 'm':
+				v.showListPicker()
+				return nil
+			case 'o':
+				v.toggleOrder()
+				return nil
+			case 'c':
+				v.showColumns()
+				return nil
+			case 'b':
+				v.showBoards()
+				return nil
+			case 'l':
+				v.showLabels(0)
+				return nil
+			case 'f':
+				v.showLabelFilter()
+				return nil
+			case '/':
+				v.startSearch()
+				return nil
+			case 'i':
+				v.toggleDetail()
+				return nil
+			case 'e':
+				v.editCurrentCardExternally()
+				return nil
+			case 'w':
+				v.logWork(1)
+				return nil
+			case 'W':
+				v.logWork(-1)
+				return nil
+			case 'u':
+				v.undoChange(false)
+				return nil
+			}
+		case tcell.KeyUp, tcell.KeyDown:
+			if !isShift(event) {
+				return event
 			}
+			if event.Key() == tcell.KeyUp {
+				v.reorderCurrentCard(-1)
+			} else {
+				v.reorderCurrentCard(1)
+			}
+			return nil
 		case tcell.KeyLeft:
-			v.moveLeft()
+			if isShift(event) {
+				v.moveCurrentCard(-1)
+			} else {
+				v.moveLeft()
+			}
 			return nil
 		case tcell.KeyRight:
-			v.moveRight()
+			if isShift(event) {
+				v.moveCurrentCard(1)
+			} else {
+				v.moveRight()
+			}
 			return nil
 		case tcell.KeyEnter:
 			v.editCurrentCard()
@@ -205,6 +344,19 @@ func (v *View) setupKeyBindings() {
 	})
 }
 
+func (v *View) boardFocused() bool {
+	focus := v.GetFocus()
+	if focus == v.grid {
+		return true
+	}
+	for _, listView := range v.listViews {
+		if focus == listView {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *View) highlightColumn(col int) {
 	if col < 0 || col >= len(v.listViews) {
 		return
@@ -220,6 +372,14 @@ func (v *View) highlightColumn(col int) {
 		}
 	}
 	v.currentCol = col
+	v.updateDetail()
+}
+
+// showBoard returns from a form or dialog to the board, restoring focus to
+// the selected column.
+func (v *View) showBoard() {
+	v.SetRoot(v.grid, true)
+	v.highlightColumn(v.currentCol)
 }
 
 func (v *View) moveLeft() {
@@ -242,25 +402,36 @@ func (v *View) createNewCard() {
 	v.showCardForm(context.Background(), nil, v.lists[v.currentCol])
 }
 
-func (v *View) editCurrentCard() {
+// currentCard returns the selected card and its list, or a nil card when
+// nothing is selected.
+func (v *View) currentCard(ctx context.Context) (*backend.Card, *backend.List) {
 	if v.currentCol >= len(v.listViews) {
-		return
+		return nil, nil
 	}
 
 	listView := v.listViews[v.currentCol]
 	currentIndex := listView.GetCurrentItem()
 	if currentIndex < 0 {
-		return
+		return nil, nil
 	}
 
 	// Get the card from the backend
 	list := v.lists[v.currentCol]
-	cards, err := list.Cards(context.Background())
+	cards, err := v.visibleCards(ctx, list)
 	if err != nil || currentIndex >= len(cards) {
+		return nil, list
+	}
+	return cards[currentIndex], list
+}
+
+func (v *View) editCurrentCard() {
+	ctx := context.Background()
+	card, list := v.currentCard(ctx)
+	if card == nil {
 		return
 	}
 
-	v.showCardForm(context.Background(), cards[currentIndex], list)
+	v.showCardForm(ctx, card, list)
 }
 
 func (v *View) editCard(ctx context.Context, card *backend.Card) {
@@ -272,82 +443,82 @@ func (v *View) editCard(ctx context.Context, card *backend.Card) {
 }
 
 func (v *View) deleteCurrentCard() {
-	if v.currentCol >= len(v.listViews) {
-		return
-	}
-
-	listView := v.listViews[v.currentCol]
-	currentIndex := listView.GetCurrentItem()
-	if currentIndex < 0 {
+	ctx := context.Background()
+	card, list := v.currentCard(ctx)
+	if card == nil {
 		return
 	}
 
-	// Get the card from the backend
-	list := v.lists[v.currentCol]
-	cards, err := list.Cards(context.Background())
-	if err != nil || currentIndex >= len(cards) {
-		return
-	}
-
-	card := cards[currentIndex]
-	
 	// Show confirmation dialog
 	modal := tview.NewModal().
-		SetText(fmt.Sprintf("Delete card '%s'?", card.Name)).
+		SetText(fmt.Sprintf("Delete card '%s'?", tview.Escape(card.Name))).
 		AddButtons([]string{"Delete", "Cancel"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 			if buttonLabel == "Delete" {
 				// Delete the card through the board's backend
-				if err := v.Board.GetBackend().DeleteCard(context.Background(), card.Id); err == nil {
+				err := v.record(ctx, fmt.Sprintf("delete '%s'", card.Name), []string{list.Id}, func() error {
+					return v.Board.GetBackend().DeleteCard(ctx, card.Id)
+				})
+				if err == nil {
 					v.refreshBoard()
 				}
 			}
-			v.SetRoot(v.grid, true)
+			v.showBoard()
 		})
 
 	v.SetRoot(modal, false)
 }
 
+// nonNegative accepts input fields holding a number that is not negative,
+// or nothing yet.
+func nonNegative(text string, _ rune) bool {
+	n, err := strconv.Atoi(text)
+	return text == "" || err == nil && n >= 0
+}
+
 func (v *View) showCardForm(ctx context.Context, card *backend.Card, list *backend.List) {
 	form := tview.NewForm()
-	
+
 	var name, description string
-	var value, effort int
-	
+	var value, effort, work int
+
 	if card != nil {
 		name = card.Name
 		description = card.Description
 		value = card.Value
 		effort = card.Effort
+		work = card.Work
 	}
 
 	form.AddInputField("Name", name, 50, nil, func(text string) {
 		name = text
 	}).
-	AddInputField("Description", description, 50, nil, func(text string) {
-		description = text
-	}).
-	AddInputField("Value", strconv.Itoa(value), 10, func(textToCheck string, lastChar rune) bool {
-		_, err := strconv.Atoi(textToCheck)
-		return err == nil || textToCheck == ""
-	}, func(text string) {
-		if text == "" {
-			value = 0
-		} else {
-			value, _ = strconv.Atoi(text)
-		}
-	}).
-	AddInputField("Effort", strconv.Itoa(effort), 10, func(textToCheck string, lastChar rune) bool {
-		_, err := strconv.Atoi(textToCheck)
-		return err == nil || textToCheck == ""
-	}, func(text string) {
-		if text == "" {
-			effort = 0
-		} else {
-			effort, _ = strconv.Atoi(text)
-		}
-	}).
-	AddButton("Save", func() {
+		AddTextArea("Description", description, 50, 8, 0, func(text string) {
+			description = text
+		}).
+		AddInputField("Value", strconv.Itoa(value), 10, nonNegative, func(text string) {
+			if text == "" {
+				value = 0
+			} else {
+				value, _ = strconv.Atoi(text)
+			}
+		}).
+		AddInputField("Effort", strconv.Itoa(effort), 10, nonNegative, func(text string) {
+			if text == "" {
+				effort = 0
+			} else {
+				effort, _ = strconv.Atoi(text)
+			}
+		}).
+		AddInputField("Work", strconv.Itoa(work), 10, nonNegative, func(text string) {
+			if text == "" {
+				work = 0
+			} else {
+				work, _ = strconv.Atoi(text)
+			}
+		})
+	labels := v.addLabelFields(form, card)
+	form.AddButton("Save", func() {
 		if name == "" {
 			return
 		}
@@ -360,42 +531,96 @@ func (v *View) showCardForm(ctx context.Context, card *backend.Card, list *backe
 				Description: description,
 				Value:       value,
 				Effort:      effort,
+				Work:        work,
+				Labels:      labels(),
 				ListId:      list.Id,
 			}
 			newCard.SetBackend(v.Board.GetBackend())
-			if err := v.Board.GetBackend().AddCard(ctx, newCard); err == nil {
-				v.refreshBoard()
-			}
-		} else {
-			// Update existing card
-			card.Name = name
-			card.Description = description
-			card.Value = value
-			card.Effort = effort
-			card.SetBackend(v.Board.GetBackend())
-			if err := card.Update(ctx); err == nil {
+			err := v.record(ctx, fmt.Sprintf("create '%s'", name), []string{list.Id}, func() error {
+				return list.AddCards(ctx, newCard)
+			})
+			if err == nil {
 				v.refreshBoard()
 			}
+			v.showBoard()
+			return
 		}
 
-		v.SetRoot(v.grid, true)
+		// Update existing card
+		card.Name = name
+		card.Description = description
+		card.Value = value
+		card.Effort = effort
+		card.Work = work
+		card.Labels = labels()
+		card.SetBackend(v.Board.GetBackend())
+		v.updateCard(ctx, card, list)
 	}).
-	AddButton("Cancel", func() {
-		v.SetRoot(v.grid, true)
-	})
+		AddButton("Cancel", func() {
+			v.showBoard()
+		})
 
 	form.SetBorder(true).SetTitle(" Card Details ")
 	v.SetRoot(form, true)
 }
 
+func (v *View) updateCard(ctx context.Context, card *backend.Card, list *backend.List) {
+	err := v.record(ctx, fmt.Sprintf("edit '%s'", card.Name), []string{card.ListId}, func() error {
+		return card.Update(ctx)
+	})
+	if errors.Is(err, backend.ErrConflict) {
+		v.resolveConflict(ctx, card, list)
+		return
+	}
+	if err == nil {
+		v.refreshBoard()
+	}
+	v.showBoard()
+}
+
+// resolveConflict is shown when card was changed elsewhere since it was
+// loaded, and lets the user reload the stored card or overwrite it with the
+// local edits.
+func (v *View) resolveConflict(ctx context.Context, card *backend.Card, list *backend.List) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Card '%s' was changed elsewhere since it was opened.", tview.Escape(card.Name))).
+		AddButtons([]string{"Reload", "Overwrite", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel == "Cancel" {
+				v.showBoard()
+				return
+			}
+			latest, err := v.Board.GetBackend().GetCard(ctx, card.Id)
+			if err != nil {
+				v.refreshBoard()
+				v.showBoard()
+				return
+			}
+			if buttonLabel == "Reload" {
+				v.showCardForm(ctx, latest, list)
+				return
+			}
+			card.Rev = latest.Rev
+			v.updateCard(ctx, card, list)
+		})
+
+	v.SetRoot(modal, false)
+}
+
 func (v *View) refreshBoard() {
 	for i, list := range v.lists {
 		if i < len(v.listViews) {
 			v.loadCards(context.Background(), v.listViews[i], list)
 		}
 	}
+	v.updateDetail()
 }
 
 func (v *View) Run() error {
+	ctx, cancel := context.WithCancel(v.Context)
+	defer cancel()
+	if err := v.watch(ctx); err != nil {
+		return err
+	}
 	return v.Application.Run()
 }
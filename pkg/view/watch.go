@@ -0,0 +1,94 @@
+package view
+
+import (
+	"context"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+// watch keeps the board in sync with changes made by other clients until
+// ctx is done. Backends that cannot report changes rely on manual refresh.
+func (v *View) watch(ctx context.Context) error {
+	w, ok := v.Board.GetBackend().(backend.Watcher)
+	if !ok {
+		return nil
+	}
+	events, err := w.Watch(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for e := range events {
+			e := e
+			v.QueueUpdateDraw(func() {
+				v.applyEvent(ctx, e)
+			})
+		}
+	}()
+	return nil
+}
+
+// applyEvent redraws the parts of the board affected by e.
+func (v *View) applyEvent(ctx context.Context, e backend.Event) {
+	if e.Err != nil {
+		v.showError("Live updates stopped, press r to refresh", e.Err)
+		return
+	}
+	be := v.Board.GetBackend()
+	switch e.Kind {
+	case backend.CardKind:
+		for i, list := range v.lists {
+			if list.Id == e.ParentId || list.Id == e.PrevParentId {
+				v.loadCards(ctx, v.listViews[i], list)
+			}
+		}
+		v.updateDetail()
+	case backend.ListKind:
+		if e.ParentId != v.Board.Id {
+			return
+		}
+		if e.Op != backend.Updated {
+			v.rebuildColumns(ctx)
+			return
+		}
+		for i, list := range v.lists {
+			if list.Id != e.Id {
+				continue
+			}
+			latest, err := be.GetList(ctx, e.Id)
+			if err != nil {
+				return
+			}
+			if latest.Pos != list.Pos {
+				v.rebuildColumns(ctx)
+				return
+			}
+			v.lists[i] = latest
+			v.loadCards(ctx, v.listViews[i], latest)
+			v.listViews[i].SetTitle(listTitle(latest))
+		}
+	case backend.BoardKind:
+		if e.Id != v.Board.Id || e.Op != backend.Updated {
+			return
+		}
+		if latest, err := be.GetBoard(ctx, e.Id); err == nil {
+			v.Board.Name = latest.Name
+			v.Board.Rev = latest.Rev
+			v.Board.Labels = latest.Labels
+			v.grid.SetTitle(v.boardTitle())
+			v.refreshBoard()
+		}
+	}
+}
+
+// rebuildColumns lays out the columns again after lists were added,
+// removed or reordered, keeping focus on the board if it had it.
+func (v *View) rebuildColumns(ctx context.Context) {
+	focused := v.boardFocused()
+	if err := v.buildColumns(ctx); err != nil {
+		return
+	}
+	if focused {
+		v.highlightColumn(v.currentCol)
+	}
+}
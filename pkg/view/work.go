@@ -0,0 +1,51 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+const progressWidth = 10
+
+// workProgress renders the work spent on card against its effort as a bar,
+// in red once the card is over budget. Cards without an estimate only show
+// the work spent.
+func workProgress(card *backend.Card) string {
+	if card.Effort <= 0 {
+		return fmt.Sprintf("Work:%d", card.Work)
+	}
+	filled := card.Work * progressWidth / card.Effort
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	bar := fmt.Sprintf("%s%s %d/%d",
+		strings.Repeat("█", filled), strings.Repeat("░", progressWidth-filled), card.Work, card.Effort)
+	if card.OverBudget() {
+		return "[red]" + bar + "[-]"
+	}
+	return bar
+}
+
+// logWork adds n to the work spent on the selected card.
+func (v *View) logWork(n int) {
+	ctx := context.Background()
+	card, list := v.currentCard(ctx)
+	if card == nil {
+		return
+	}
+	err := v.record(ctx, fmt.Sprintf("log work on '%s'", card.Name), []string{list.Id}, func() error {
+		return card.LogWork(ctx, n)
+	})
+	if err != nil {
+		v.showError(fmt.Sprintf("Could not log work on card '%s'", card.Name), err)
+		return
+	}
+	v.refreshBoard()
+	v.selectCard(ctx, v.currentCol, card.Id)
+}
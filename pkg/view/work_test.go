@@ -0,0 +1,24 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/twistedogic/orga/pkg/backend"
+)
+
+func TestWorkProgress(t *testing.T) {
+	cases := []struct {
+		effort, work int
+		want         string
+	}{
+		{0, 3, "Work:3"},
+		{4, 2, "█████░░░░░ 2/4"},
+		{2, 3, "[red]██████████ 3/2[-]"},
+		{2, -5, "░░░░░░░░░░ -5/2"},
+	}
+	for _, c := range cases {
+		if got := workProgress(&backend.Card{Effort: c.effort, Work: c.work}); got != c.want {
+			t.Errorf("%d/%d: want %q, got %q", c.work, c.effort, c.want, got)
+		}
+	}
+}